@@ -37,19 +37,14 @@ data kube_manifest_documents "all" {
     ]
 }
 
-data "kube_parsed_manifest" "document"{
-    for_each = data.kube_manifest_documents.all.documents
-    text = each.value.text 
-}
-
 resource "kube_applied_manifest" "cluster" {
     for_each = { for k,v in data.kube_manifest_documents.all.documents: k => v if v.metadata.namespace == "" }   
-    manifest = data.kube_parsed_manifest.document[each.key].manifest
+    manifest = each.value.manifest
 }
 
 resource "kube_applied_manifest" "namespaced" {
     for_each = { for k,v in data.kube_manifest_documents.all.documents: k => v if v.metadata.namespace != "" }   
-    manifest = data.kube_parsed_manifest.document[each.key].manifest
+    manifest = each.value.manifest
     fetch = {
         "conditions": {
             field = "status.conditions"
@@ -59,6 +54,7 @@ resource "kube_applied_manifest" "namespaced" {
 }
 
 data "kube_files" "test" {
+    root = "${abspath(path.module)}/files"
     file_sets = [
         {
             paths = [
@@ -99,6 +95,23 @@ resource "kube_applied_manifest" "configmap" {
     }
 }
 
+resource "kube_config_generator" "files" {
+    name = "files"
+    namespace = "example"
+    file_sets = [
+        {
+            paths = [
+                "${abspath(path.module)}/files/*.txt"
+            ]
+        }
+    ]
+    literals = {
+        "inline_test1" = "content_inline_test_1"
+    }
+    keep_versions = 2
+    depends_on = [kube_applied_manifest.cluster]
+}
+
 data "kube_query" "test" {
     api_version = "v1"
     kind = "ConfigMap"
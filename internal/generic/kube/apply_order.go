@@ -0,0 +1,112 @@
+package kube
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const DefaultWaveAnnotation = "argocd.argoproj.io/sync-wave"
+
+// kindPriorities orders built-in kinds so that each kind is applied after the kinds it usually
+// depends on. Kinds which are not listed use customResourcePriority.
+var kindPriorities = map[schema.GroupKind]int{
+	{Kind: "Namespace"}: 0,
+
+	{Group: "apiextensions.k8s.io", Kind: "CustomResourceDefinition"}: 1,
+
+	{Group: "scheduling.k8s.io", Kind: "PriorityClass"}:       2,
+	{Group: "storage.k8s.io", Kind: "StorageClass"}:           2,
+	{Group: "node.k8s.io", Kind: "RuntimeClass"}:              2,
+	{Group: "networking.k8s.io", Kind: "IngressClass"}:        2,
+	{Group: "networking.k8s.io", Kind: "NetworkPolicy"}:       2,
+	{Kind: "ResourceQuota"}:                                   2,
+	{Kind: "LimitRange"}:                                      2,
+	{Kind: "PersistentVolume"}:                                2,
+	{Kind: "ServiceAccount"}:                                  3,
+	{Group: "rbac.authorization.k8s.io", Kind: "ClusterRole"}: 4,
+	{Group: "rbac.authorization.k8s.io", Kind: "Role"}:        4,
+
+	{Group: "rbac.authorization.k8s.io", Kind: "ClusterRoleBinding"}: 5,
+	{Group: "rbac.authorization.k8s.io", Kind: "RoleBinding"}:        5,
+
+	{Kind: "ConfigMap"}:             6,
+	{Kind: "Secret"}:                6,
+	{Kind: "PersistentVolumeClaim"}: 6,
+	{Kind: "Service"}:               7,
+
+	{Kind: "Pod"}:                        8,
+	{Kind: "ReplicationController"}:      8,
+	{Group: "apps", Kind: "Deployment"}:  8,
+	{Group: "apps", Kind: "StatefulSet"}: 8,
+	{Group: "apps", Kind: "DaemonSet"}:   8,
+	{Group: "apps", Kind: "ReplicaSet"}:  8,
+	{Group: "batch", Kind: "Job"}:        8,
+	{Group: "batch", Kind: "CronJob"}:    8,
+
+	{Group: "autoscaling", Kind: "HorizontalPodAutoscaler"}: 9,
+	{Group: "policy", Kind: "PodDisruptionBudget"}:          9,
+	{Group: "networking.k8s.io", Kind: "Ingress"}:           9,
+
+	{Group: "apiregistration.k8s.io", Kind: "APIService"}: 11,
+
+	{Group: "admissionregistration.k8s.io", Kind: "ValidatingAdmissionPolicy"}:        12,
+	{Group: "admissionregistration.k8s.io", Kind: "ValidatingAdmissionPolicyBinding"}: 12,
+	{Group: "admissionregistration.k8s.io", Kind: "MutatingWebhookConfiguration"}:     12,
+	{Group: "admissionregistration.k8s.io", Kind: "ValidatingWebhookConfiguration"}:   12,
+}
+
+const customResourcePriority = 10
+
+func KindPriority(gk schema.GroupKind) int {
+	priority, found := kindPriorities[gk]
+	if !found {
+		return customResourcePriority
+	}
+	return priority
+}
+
+// Wave returns the integer value of the wave annotation, or 0 when it is not set.
+func Wave(u unstructured.Unstructured, annotation string) (int, error) {
+	value, found := u.GetAnnotations()[annotation]
+	if !found || value == "" {
+		return 0, nil
+	}
+	wave, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("annotation %s must be an integer: %w", annotation, err)
+	}
+	return wave, nil
+}
+
+type ApplyOrderKey struct {
+	Wave     int
+	Priority int
+}
+
+// ApplyOrder returns the tier of each key. Keys are sorted by wave and then kind priority, and
+// equal keys share a tier, so tiers are numbered from 0 without gaps.
+func ApplyOrder(keys []ApplyOrderKey) []int {
+	sorted := make([]ApplyOrderKey, len(keys))
+	copy(sorted, keys)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Wave != sorted[j].Wave {
+			return sorted[i].Wave < sorted[j].Wave
+		}
+		return sorted[i].Priority < sorted[j].Priority
+	})
+	tiers := make(map[ApplyOrderKey]int)
+	for _, key := range sorted {
+		if _, found := tiers[key]; !found {
+			tiers[key] = len(tiers)
+		}
+	}
+	result := make([]int, len(keys))
+	for i, key := range keys {
+		result[i] = tiers[key]
+	}
+	return result
+}
@@ -0,0 +1,51 @@
+package kube
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestApplyOrder(t *testing.T) {
+	namespace := KindPriority(schema.GroupKind{Kind: "Namespace"})
+	crd := KindPriority(schema.GroupKind{Group: "apiextensions.k8s.io", Kind: "CustomResourceDefinition"})
+	deployment := KindPriority(schema.GroupKind{Group: "apps", Kind: "Deployment"})
+	widget := KindPriority(schema.GroupKind{Group: "example.com", Kind: "Widget"})
+	webhook := KindPriority(schema.GroupKind{Group: "admissionregistration.k8s.io", Kind: "ValidatingWebhookConfiguration"})
+	if !(namespace < crd && crd < deployment && deployment < widget && widget < webhook) {
+		t.Fatalf("unexpected kind priorities %d %d %d %d %d", namespace, crd, deployment, widget, webhook)
+	}
+
+	keys := []ApplyOrderKey{
+		{Wave: 0, Priority: deployment},
+		{Wave: 0, Priority: namespace},
+		{Wave: -1, Priority: widget},
+		{Wave: 0, Priority: deployment},
+		{Wave: 0, Priority: webhook},
+	}
+	expected := []int{2, 1, 0, 2, 3}
+	actual := ApplyOrder(keys)
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+}
+
+func TestWave(t *testing.T) {
+	u := unstructured.Unstructured{Object: map[string]any{}}
+	wave, err := Wave(u, DefaultWaveAnnotation)
+	if err != nil || wave != 0 {
+		t.Errorf("expected wave 0, got %d %v", wave, err)
+	}
+	u.SetAnnotations(map[string]string{DefaultWaveAnnotation: "-2"})
+	wave, err = Wave(u, DefaultWaveAnnotation)
+	if err != nil || wave != -2 {
+		t.Errorf("expected wave -2, got %d %v", wave, err)
+	}
+	u.SetAnnotations(map[string]string{DefaultWaveAnnotation: "first"})
+	_, err = Wave(u, DefaultWaveAnnotation)
+	if err == nil {
+		t.Errorf("expected an error for a non integer wave")
+	}
+}
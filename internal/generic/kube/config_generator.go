@@ -0,0 +1,141 @@
+package kube
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path"
+	"path/filepath"
+	"unicode/utf8"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const DefaultKeepVersions = 3
+
+// ConfigGenerator builds an immutable ConfigMap or Secret whose name ends with a hash of its
+// content, so workloads which reference it roll when the content changes.
+type ConfigGenerator struct {
+	Kind        string
+	Name        string
+	Namespace   string
+	Type        string
+	Labels      map[string]string
+	Annotations map[string]string
+	Data        StringMap
+	sensitive   bool
+	// sources is the file each key was read from, so a collision can name both files
+	sources map[string]string
+}
+
+// AddFiles adds the content of each file keyed by its base name, since keys may not contain a
+// "/". Two files with the same base name are an error. Text goes to data or stringData and other
+// content to binaryData or data.
+func (g *ConfigGenerator) AddFiles(fsds FileSetDefs) error {
+	var handler ExpandedContentHandlerFunc = func(content *ExpandedContent) error {
+		key := path.Base(filepath.ToSlash(content.Filename))
+		if previous, found := g.sources[key]; found {
+			return fmt.Errorf("%s and %s both have the key %s, as keys are the base names of the files", previous, content.Filename, key)
+		}
+		if g.sources == nil {
+			g.sources = make(map[string]string)
+		}
+		g.sources[key] = content.Filename
+		g.sensitive = g.sensitive || content.Sensitive
+		var err error
+		if utf8.Valid(content.Content) {
+			err = g.Data.AddText(key, string(content.Content))
+		} else {
+			err = g.Data.EncodeAsBase64AndAdd(key, content.Content)
+		}
+		if err != nil {
+			return fmt.Errorf("error adding %s: %w", content.Filename, err)
+		}
+		return nil
+	}
+	return fsds.ExpandContent(handler)
+}
+
+// Generate returns the object and the hash which is appended to its name.
+func (g *ConfigGenerator) Generate() (unstructured.Unstructured, string, error) {
+	u := unstructured.Unstructured{Object: map[string]any{}}
+	u.SetAPIVersion("v1")
+	u.SetKind(FirstNonNullString(g.Kind, "ConfigMap"))
+	switch u.GetKind() {
+	case "ConfigMap":
+		if g.Type != "" {
+			return u, "", fmt.Errorf("type is only supported for secrets")
+		}
+		if g.sensitive {
+			return u, "", fmt.Errorf("decrypted content must be stored in a Secret")
+		}
+		setNonEmpty(u.Object, g.Data.GetUnstructuredText(), "data")
+		setNonEmpty(u.Object, g.Data.GetUnstructuredBase64(), "binaryData")
+	case "Secret":
+		u.Object["type"] = FirstNonNullString(g.Type, "Opaque")
+		setNonEmpty(u.Object, g.Data.GetUnstructuredText(), "stringData")
+		setNonEmpty(u.Object, g.Data.GetUnstructuredBase64(), "data")
+	default:
+		return u, "", fmt.Errorf("kind must be ConfigMap or Secret, not %s", u.GetKind())
+	}
+	if g.Name == "" {
+		return u, "", fmt.Errorf("name is empty")
+	}
+	hash, err := ContentHash(u)
+	if err != nil {
+		return u, "", err
+	}
+	u.SetName(g.Name + "-" + hash)
+	if g.Namespace != "" {
+		u.SetNamespace(g.Namespace)
+	}
+	if len(g.Labels) > 0 {
+		u.SetLabels(g.Labels)
+	}
+	if len(g.Annotations) > 0 {
+		u.SetAnnotations(g.Annotations)
+	}
+	u.Object["immutable"] = true
+	return u, hash, nil
+}
+
+func setNonEmpty(obj map[string]any, value map[string]any, field string) {
+	if len(value) > 0 {
+		obj[field] = value
+	}
+}
+
+// ContentHash returns the first 10 hex digits of the sha256 of the kind, type and data of a
+// ConfigMap or Secret.
+func ContentHash(u unstructured.Unstructured) (string, error) {
+	content := map[string]any{"kind": u.GetKind()}
+	for _, field := range []string{"type", "data", "binaryData", "stringData"} {
+		if value, found := u.Object[field]; found {
+			content[field] = value
+		}
+	}
+	data, err := json.Marshal(content)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])[:10], nil
+}
+
+// KeepVersions puts current at the front of the previous versions and returns the newest keep
+// versions along with the older versions which should be deleted.
+func KeepVersions(versions []string, current string, keep int) (kept []string, pruned []string) {
+	kept = []string{current}
+	for _, version := range versions {
+		if version == current {
+			continue
+		}
+		if len(kept) < max(keep, 1) {
+			kept = append(kept, version)
+		} else {
+			pruned = append(pruned, version)
+		}
+	}
+	return kept, pruned
+}
@@ -0,0 +1,119 @@
+package kube
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestConfigGenerator(t *testing.T) {
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "app.conf"), []byte("port=80\n"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(dir, "keystore.bin"), []byte{0xff, 0xfe, 0x00}, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fsds := FileSetDefs{{GlobPaths: []string{filepath.Join(dir, "*")}}}
+
+	g := &ConfigGenerator{Name: "app", Namespace: "web"}
+	err = g.AddFiles(fsds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, hash, err := g.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.GetName() != "app-"+hash || len(hash) != 10 {
+		t.Errorf("unexpected name %s for hash %s", u.GetName(), hash)
+	}
+	if u.Object["immutable"] != true {
+		t.Errorf("expected the config map to be immutable")
+	}
+	if u.Object["data"].(map[string]any)["app.conf"] != "port=80\n" {
+		t.Errorf("expected app.conf in data, got %v", u.Object["data"])
+	}
+	if u.Object["binaryData"].(map[string]any)["keystore.bin"] != "//4A" {
+		t.Errorf("expected keystore.bin in binaryData, got %v", u.Object["binaryData"])
+	}
+
+	secret := &ConfigGenerator{Kind: "Secret", Name: "app"}
+	err = secret.AddFiles(fsds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, secretHash, err := secret.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secretHash == hash {
+		t.Errorf("expected the kind to change the hash")
+	}
+	if s.Object["stringData"].(map[string]any)["app.conf"] != "port=80\n" || s.Object["data"].(map[string]any)["keystore.bin"] != "//4A" {
+		t.Errorf("unexpected secret content %v", s.Object)
+	}
+
+	changed := &ConfigGenerator{Name: "app", Namespace: "other", Labels: map[string]string{"a": "b"}}
+	err = changed.AddFiles(fsds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = changed.Data.AddText("extra", "value")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, changedHash, err := changed.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changedHash == hash {
+		t.Errorf("expected the content to change the hash")
+	}
+
+	_, _, err = (&ConfigGenerator{Kind: "Pod", Name: "app"}).Generate()
+	if err == nil || !strings.Contains(err.Error(), "ConfigMap or Secret") {
+		t.Errorf("expected a kind error, got %v", err)
+	}
+}
+
+func TestKeepVersions(t *testing.T) {
+	testCases := []struct {
+		versions []string
+		current  string
+		keep     int
+		kept     []string
+		pruned   []string
+	}{
+		{nil, "a-1", 3, []string{"a-1"}, nil},
+		{[]string{"a-2", "a-1"}, "a-3", 3, []string{"a-3", "a-2", "a-1"}, nil},
+		{[]string{"a-3", "a-2", "a-1"}, "a-4", 2, []string{"a-4", "a-3"}, []string{"a-2", "a-1"}},
+		{[]string{"a-2", "a-1"}, "a-1", 3, []string{"a-1", "a-2"}, nil},
+		{[]string{"a-1"}, "a-2", 0, []string{"a-2"}, []string{"a-1"}},
+	}
+	for _, tc := range testCases {
+		kept, pruned := KeepVersions(tc.versions, tc.current, tc.keep)
+		if !reflect.DeepEqual(kept, tc.kept) || !reflect.DeepEqual(pruned, tc.pruned) {
+			t.Errorf("KeepVersions(%v, %s, %d) = %v, %v, expected %v, %v", tc.versions, tc.current, tc.keep, kept, pruned, tc.kept, tc.pruned)
+		}
+	}
+}
+
+func TestConfigGeneratorKeyCollision(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, "a/config.yaml", "b/config.yaml")
+	g := &ConfigGenerator{Name: "app"}
+	err := g.AddFiles(FileSetDefs{{GlobPaths: []string{filepath.Join(dir, "*", "config.yaml")}}})
+	if err == nil {
+		t.Fatal("expected an error for two files with the same base name")
+	}
+	for _, name := range []string{filepath.Join("a", "config.yaml"), filepath.Join("b", "config.yaml")} {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("expected the error to name %s, got %v", name, err)
+		}
+	}
+}
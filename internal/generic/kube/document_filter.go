@@ -0,0 +1,105 @@
+package kube
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// DocumentFilter matches a document when every criteria which is set matches. A criteria with
+// several values matches when any of them does. Groups use "" for the core group and namespaces
+// use "" for cluster scoped documents.
+type DocumentFilter struct {
+	Kinds         []string
+	Groups        []string
+	Namespaces    []string
+	Names         []string
+	LabelSelector string
+	Files         []string
+}
+
+func matchesAnyGlob(patterns []string, value string, match func(pattern, value string) (bool, error)) (bool, error) {
+	for _, pattern := range patterns {
+		matched, err := match(pattern, value)
+		if err != nil {
+			return false, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+		if matched {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// matchFile matches patterns without a separator against the base name of the file.
+func matchFile(pattern, filename string) (bool, error) {
+	if !strings.Contains(pattern, "/") {
+		filename = path.Base(filename)
+	}
+	return doublestar.Match(pattern, filename)
+}
+
+func (df *DocumentFilter) Matches(u unstructured.Unstructured, filename string) (bool, error) {
+	gvk := u.GroupVersionKind()
+	if len(df.Kinds) > 0 && !slices.Contains(df.Kinds, gvk.Kind) {
+		return false, nil
+	}
+	if len(df.Groups) > 0 && !slices.Contains(df.Groups, gvk.Group) {
+		return false, nil
+	}
+	if len(df.Namespaces) > 0 && !slices.Contains(df.Namespaces, u.GetNamespace()) {
+		return false, nil
+	}
+	if len(df.Names) > 0 {
+		matched, err := matchesAnyGlob(df.Names, u.GetName(), path.Match)
+		if err != nil || !matched {
+			return false, err
+		}
+	}
+	if df.LabelSelector != "" {
+		selector, err := labels.Parse(df.LabelSelector)
+		if err != nil {
+			return false, fmt.Errorf("invalid label selector %q: %w", df.LabelSelector, err)
+		}
+		if !selector.Matches(labels.Set(u.GetLabels())) {
+			return false, nil
+		}
+	}
+	if len(df.Files) > 0 {
+		matched, err := matchesAnyGlob(df.Files, filename, matchFile)
+		if err != nil || !matched {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+type DocumentFilters []DocumentFilter
+
+func (dfs DocumentFilters) MatchesAny(u unstructured.Unstructured, filename string) (bool, error) {
+	for i := range dfs {
+		matched, err := dfs[i].Matches(u, filename)
+		if err != nil || matched {
+			return matched, err
+		}
+	}
+	return false, nil
+}
+
+// IsSelected returns true when a document matches one of the include filters ( or there are none )
+// and none of the exclude filters.
+func IsSelected(include, exclude DocumentFilters, u unstructured.Unstructured, filename string) (bool, error) {
+	if len(include) > 0 {
+		matched, err := include.MatchesAny(u, filename)
+		if err != nil || !matched {
+			return false, err
+		}
+	}
+	excluded, err := exclude.MatchesAny(u, filename)
+	return !excluded, err
+}
@@ -0,0 +1,82 @@
+package kube
+
+import (
+	"path/filepath"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestIsSelected(t *testing.T) {
+	deployment := unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata": map[string]any{
+			"name":      "web-frontend",
+			"namespace": "example",
+			"labels":    map[string]any{"tier": "frontend"},
+		},
+	}}
+	namespace := unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "v1",
+		"kind":       "Namespace",
+		"metadata":   map[string]any{"name": "example"},
+	}}
+	testCases := []struct {
+		name     string
+		include  DocumentFilters
+		exclude  DocumentFilters
+		u        unstructured.Unstructured
+		filename string
+		expected bool
+		err      bool
+	}{
+		{name: "no filters", u: deployment, expected: true},
+		{name: "kind", include: DocumentFilters{{Kinds: []string{"Deployment"}}}, u: deployment, expected: true},
+		{name: "other kind", include: DocumentFilters{{Kinds: []string{"Service"}}}, u: deployment, expected: false},
+		{name: "core group", include: DocumentFilters{{Groups: []string{""}}}, u: namespace, expected: true},
+		{name: "cluster scoped", exclude: DocumentFilters{{Namespaces: []string{""}}}, u: namespace, expected: false},
+		{name: "name glob", include: DocumentFilters{{Names: []string{"web-*"}}}, u: deployment, expected: true},
+		{name: "label selector", include: DocumentFilters{{LabelSelector: "tier in (frontend,backend)"}}, u: deployment, expected: true},
+		{name: "label selector mismatch", include: DocumentFilters{{LabelSelector: "tier=backend"}}, u: deployment, expected: false},
+		{name: "all criteria must match", include: DocumentFilters{{Kinds: []string{"Deployment"}, Namespaces: []string{"other"}}}, u: deployment, expected: false},
+		{name: "any filter may match", include: DocumentFilters{{Kinds: []string{"Service"}}, {Groups: []string{"apps"}}}, u: deployment, expected: true},
+		{name: "file base name", exclude: DocumentFilters{{Files: []string{"*.generated.yaml"}}}, u: deployment, filename: "/src/apps/web.generated.yaml", expected: false},
+		{name: "file path", include: DocumentFilters{{Files: []string{"/src/**/web.yaml"}}}, u: deployment, filename: "/src/apps/web.yaml", expected: true},
+		{name: "invalid selector", include: DocumentFilters{{LabelSelector: "tier in ("}}, u: deployment, err: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			selected, err := IsSelected(tc.include, tc.exclude, tc.u, tc.filename)
+			if (err != nil) != tc.err {
+				t.Fatalf("expected error %v, got %v", tc.err, err)
+			}
+			if selected != tc.expected {
+				t.Errorf("expected %v, got %v", tc.expected, selected)
+			}
+		})
+	}
+}
+
+func TestFilterPath(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	testCases := []struct {
+		fsd      FileSetDef
+		filename string
+	}{
+		{filename: filepath.Join(dir, "manifests", "web.yaml")},
+		{filename: filepath.Join("manifests", "web.yaml")},
+		{fsd: FileSetDef{Git: &GitOptions{Repository: "/repo"}}, filename: "/repo@abc123:manifests/web.yaml"},
+	}
+	for _, tc := range testCases {
+		filterPath := tc.fsd.FilterPath(tc.filename)
+		matched, err := matchFile("manifests/*.yaml", filterPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !matched {
+			t.Errorf("expected %s ( %s ) to match manifests/*.yaml", tc.filename, filterPath)
+		}
+	}
+}
@@ -0,0 +1,275 @@
+package kube
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/yaml"
+)
+
+// ImageOverride rewrites container images whose name matches Name, like kustomize `images:`.
+type ImageOverride struct {
+	Name    string
+	NewName string
+	NewTag  string
+	Digest  string
+}
+
+type TransformOptions struct {
+	Namespace        string
+	Labels           map[string]string
+	Annotations      map[string]string
+	IncludeSelectors bool
+	Images           []ImageOverride
+	// IsNamespaced asks the cluster, and is used for every kind which is not defined by an
+	// earlier CustomResourceDefinition in the file sets. Without it, or when the cluster does not
+	// know the kind, built-in kinds fall back to a table.
+	IsNamespaced func(apiVersion, kind string) (bool, error)
+
+	crdScopes map[schema.GroupKind]bool
+}
+
+var clusterScopedKinds = map[schema.GroupKind]bool{
+	{Kind: "Namespace"}:        true,
+	{Kind: "Node"}:             true,
+	{Kind: "PersistentVolume"}: true,
+	{Kind: "ComponentStatus"}:  true,
+
+	{Group: "rbac.authorization.k8s.io", Kind: "ClusterRole"}:                         true,
+	{Group: "rbac.authorization.k8s.io", Kind: "ClusterRoleBinding"}:                  true,
+	{Group: "apiextensions.k8s.io", Kind: "CustomResourceDefinition"}:                 true,
+	{Group: "apiregistration.k8s.io", Kind: "APIService"}:                             true,
+	{Group: "storage.k8s.io", Kind: "StorageClass"}:                                   true,
+	{Group: "storage.k8s.io", Kind: "CSIDriver"}:                                      true,
+	{Group: "storage.k8s.io", Kind: "CSINode"}:                                        true,
+	{Group: "storage.k8s.io", Kind: "VolumeAttachment"}:                               true,
+	{Group: "scheduling.k8s.io", Kind: "PriorityClass"}:                               true,
+	{Group: "node.k8s.io", Kind: "RuntimeClass"}:                                      true,
+	{Group: "networking.k8s.io", Kind: "IngressClass"}:                                true,
+	{Group: "networking.k8s.io", Kind: "IPAddress"}:                                   true,
+	{Group: "networking.k8s.io", Kind: "ServiceCIDR"}:                                 true,
+	{Group: "storage.k8s.io", Kind: "VolumeAttributesClass"}:                          true,
+	{Group: "certificates.k8s.io", Kind: "CertificateSigningRequest"}:                 true,
+	{Group: "admissionregistration.k8s.io", Kind: "MutatingWebhookConfiguration"}:     true,
+	{Group: "admissionregistration.k8s.io", Kind: "ValidatingWebhookConfiguration"}:   true,
+	{Group: "admissionregistration.k8s.io", Kind: "ValidatingAdmissionPolicy"}:        true,
+	{Group: "admissionregistration.k8s.io", Kind: "ValidatingAdmissionPolicyBinding"}: true,
+	{Group: "flowcontrol.apiserver.k8s.io", Kind: "FlowSchema"}:                       true,
+	{Group: "flowcontrol.apiserver.k8s.io", Kind: "PriorityLevelConfiguration"}:       true,
+	{Group: "policy", Kind: "PodSecurityPolicy"}:                                      true,
+}
+
+var namespacedBuiltinGroups = map[string]bool{
+	"": true, "apps": true, "batch": true, "autoscaling": true, "policy": true,
+	"networking.k8s.io": true, "rbac.authorization.k8s.io": true, "coordination.k8s.io": true,
+	"discovery.k8s.io": true, "events.k8s.io": true,
+}
+
+// podTemplatePaths lists where the pod template lives for each workload kind.
+var podTemplatePaths = map[schema.GroupKind][]string{
+	{Kind: "ReplicationController"}:      {"spec", "template"},
+	{Group: "apps", Kind: "Deployment"}:  {"spec", "template"},
+	{Group: "apps", Kind: "StatefulSet"}: {"spec", "template"},
+	{Group: "apps", Kind: "DaemonSet"}:   {"spec", "template"},
+	{Group: "apps", Kind: "ReplicaSet"}:  {"spec", "template"},
+	{Group: "batch", Kind: "Job"}:        {"spec", "template"},
+	{Group: "batch", Kind: "CronJob"}:    {"spec", "jobTemplate", "spec", "template"},
+}
+
+// selectorPaths lists the label selectors which must match the pod template labels.
+var selectorPaths = map[schema.GroupKind][]string{
+	{Kind: "Service"}:                              {"spec", "selector"},
+	{Kind: "ReplicationController"}:                {"spec", "selector"},
+	{Group: "apps", Kind: "Deployment"}:            {"spec", "selector", "matchLabels"},
+	{Group: "apps", Kind: "StatefulSet"}:           {"spec", "selector", "matchLabels"},
+	{Group: "apps", Kind: "DaemonSet"}:             {"spec", "selector", "matchLabels"},
+	{Group: "apps", Kind: "ReplicaSet"}:            {"spec", "selector", "matchLabels"},
+	{Group: "policy", Kind: "PodDisruptionBudget"}: {"spec", "selector", "matchLabels"},
+}
+
+// isNamespaced prefers discovery to the built-in table, which only guesses that kinds of the
+// built-in groups it does not list are namespaced.
+func (t *TransformOptions) isNamespaced(u *unstructured.Unstructured) (bool, error) {
+	gk := u.GroupVersionKind().GroupKind()
+	if namespaced, found := t.crdScopes[gk]; found {
+		return namespaced, nil
+	}
+	var discoveryErr error
+	if t.IsNamespaced != nil {
+		namespaced, err := t.IsNamespaced(u.GetAPIVersion(), gk.Kind)
+		if err == nil {
+			return namespaced, nil
+		}
+		discoveryErr = err
+	}
+	if clusterScopedKinds[gk] {
+		return false, nil
+	}
+	if namespacedBuiltinGroups[gk.Group] {
+		return true, nil
+	}
+	if discoveryErr != nil {
+		return false, fmt.Errorf("cannot tell whether %s is namespaced: %w", gk, discoveryErr)
+	}
+	return false, fmt.Errorf("cannot tell whether %s is namespaced without discovery", gk)
+}
+
+// recordCustomResourceDefinition remembers the scope of kinds defined in the file sets, so their
+// objects can be transformed before the definition is installed.
+func (t *TransformOptions) recordCustomResourceDefinition(u *unstructured.Unstructured) {
+	if u.GroupVersionKind().GroupKind() != (schema.GroupKind{Group: "apiextensions.k8s.io", Kind: "CustomResourceDefinition"}) {
+		return
+	}
+	group, _, _ := unstructured.NestedString(u.Object, "spec", "group")
+	kind, _, _ := unstructured.NestedString(u.Object, "spec", "names", "kind")
+	scope, _, _ := unstructured.NestedString(u.Object, "spec", "scope")
+	if t.crdScopes == nil {
+		t.crdScopes = make(map[schema.GroupKind]bool)
+	}
+	t.crdScopes[schema.GroupKind{Group: group, Kind: kind}] = scope != "Cluster"
+}
+
+func mergeStringMap(obj map[string]any, values map[string]string, fields ...string) error {
+	if len(values) == 0 {
+		return nil
+	}
+	existing, _, err := unstructured.NestedStringMap(obj, fields...)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		existing = make(map[string]string)
+	}
+	for k, v := range values {
+		existing[k] = v
+	}
+	return unstructured.SetNestedStringMap(obj, existing, fields...)
+}
+
+// splitImage splits a reference into name, tag and digest.
+func splitImage(image string) (name, tag, digest string) {
+	name, digest, _ = strings.Cut(image, "@")
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name, tag = name[:i], name[i+1:]
+	}
+	return name, tag, digest
+}
+
+func (o ImageOverride) apply(image string) (string, bool) {
+	name, tag, digest := splitImage(image)
+	if name != o.Name {
+		return image, false
+	}
+	if o.NewName != "" {
+		name = o.NewName
+	}
+	if o.NewTag != "" {
+		tag, digest = o.NewTag, ""
+	}
+	if o.Digest != "" {
+		tag, digest = "", o.Digest
+	}
+	result := name
+	if tag != "" {
+		result += ":" + tag
+	}
+	if digest != "" {
+		result += "@" + digest
+	}
+	return result, true
+}
+
+func (t *TransformOptions) overrideImages(podSpec map[string]any) {
+	for _, field := range []string{"initContainers", "containers", "ephemeralContainers"} {
+		containers, _ := podSpec[field].([]any)
+		for _, c := range containers {
+			container, ok := c.(map[string]any)
+			if !ok {
+				continue
+			}
+			image, _ := container["image"].(string)
+			for _, override := range t.Images {
+				if newImage, matched := override.apply(image); matched {
+					container["image"] = newImage
+					break
+				}
+			}
+		}
+	}
+}
+
+func (t *TransformOptions) Transform(u *unstructured.Unstructured) error {
+	t.recordCustomResourceDefinition(u)
+	gk := u.GroupVersionKind().GroupKind()
+
+	if t.Namespace != "" && u.GetNamespace() == "" {
+		namespaced, err := t.isNamespaced(u)
+		if err != nil {
+			return err
+		}
+		if namespaced {
+			u.SetNamespace(t.Namespace)
+		}
+	}
+	err := mergeStringMap(u.Object, t.Labels, "metadata", "labels")
+	if err != nil {
+		return err
+	}
+	err = mergeStringMap(u.Object, t.Annotations, "metadata", "annotations")
+	if err != nil {
+		return err
+	}
+	if t.IncludeSelectors {
+		if path, found := selectorPaths[gk]; found {
+			err = mergeStringMap(u.Object, t.Labels, path...)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	var podSpec map[string]any
+	if path, found := podTemplatePaths[gk]; found {
+		template, found, err := unstructured.NestedMap(u.Object, path...)
+		if err != nil || !found {
+			return err
+		}
+		err = mergeStringMap(template, t.Labels, "metadata", "labels")
+		if err != nil {
+			return err
+		}
+		err = mergeStringMap(template, t.Annotations, "metadata", "annotations")
+		if err != nil {
+			return err
+		}
+		podSpec, _ = template["spec"].(map[string]any)
+		t.overrideImages(podSpec)
+		return unstructured.SetNestedMap(u.Object, template, path...)
+	}
+	if gk == (schema.GroupKind{Kind: "Pod"}) {
+		podSpec, _ = u.Object["spec"].(map[string]any)
+		t.overrideImages(podSpec)
+	}
+	return nil
+}
+
+// TransformDocument parses a single document, transforms it and returns it as yaml. A document
+// which the transform does not change is returned as it is, keeping its comments and key order.
+func (t *TransformOptions) TransformDocument(content []byte) ([]byte, error) {
+	u, err := ParseSingleYamlManifest(string(content))
+	if err != nil {
+		return nil, err
+	}
+	original := u.DeepCopy()
+	err = t.Transform(&u)
+	if err != nil {
+		return nil, err
+	}
+	if reflect.DeepEqual(original.Object, u.Object) {
+		return content, nil
+	}
+	return yaml.Marshal(u.Object)
+}
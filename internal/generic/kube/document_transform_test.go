@@ -0,0 +1,211 @@
+package kube
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestSplitImage(t *testing.T) {
+	testCases := []struct {
+		image, name, tag, digest string
+	}{
+		{"nginx", "nginx", "", ""},
+		{"nginx:1.27", "nginx", "1.27", ""},
+		{"registry:5000/team/app", "registry:5000/team/app", "", ""},
+		{"registry:5000/team/app:v1@sha256:abc", "registry:5000/team/app", "v1", "sha256:abc"},
+	}
+	for _, tc := range testCases {
+		name, tag, digest := splitImage(tc.image)
+		if name != tc.name || tag != tc.tag || digest != tc.digest {
+			t.Errorf("splitImage(%q) = %q %q %q", tc.image, name, tag, digest)
+		}
+	}
+}
+
+func TestTransform(t *testing.T) {
+	transform := &TransformOptions{
+		Namespace:        "example",
+		Labels:           map[string]string{"team": "web"},
+		Annotations:      map[string]string{"owner": "ops"},
+		IncludeSelectors: true,
+		Images: []ImageOverride{
+			{Name: "nginx", NewTag: "1.27"},
+			{Name: "busybox", NewName: "mirror/busybox", Digest: "sha256:abc"},
+		},
+		IsNamespaced: func(apiVersion, kind string) (bool, error) {
+			return false, fmt.Errorf("no cluster")
+		},
+	}
+	deployment := unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]any{"name": "web"},
+		"spec": map[string]any{
+			"selector": map[string]any{"matchLabels": map[string]any{"app": "web"}},
+			"template": map[string]any{
+				"metadata": map[string]any{"labels": map[string]any{"app": "web"}},
+				"spec": map[string]any{
+					"initContainers": []any{map[string]any{"name": "init", "image": "busybox:1.36"}},
+					"containers":     []any{map[string]any{"name": "web", "image": "nginx:1.25"}},
+				},
+			},
+		},
+	}}
+	err := transform.Transform(&deployment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]any{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata": map[string]any{
+			"name":        "web",
+			"namespace":   "example",
+			"labels":      map[string]any{"team": "web"},
+			"annotations": map[string]any{"owner": "ops"},
+		},
+		"spec": map[string]any{
+			"selector": map[string]any{"matchLabels": map[string]any{"app": "web", "team": "web"}},
+			"template": map[string]any{
+				"metadata": map[string]any{
+					"labels":      map[string]any{"app": "web", "team": "web"},
+					"annotations": map[string]any{"owner": "ops"},
+				},
+				"spec": map[string]any{
+					"initContainers": []any{map[string]any{"name": "init", "image": "mirror/busybox@sha256:abc"}},
+					"containers":     []any{map[string]any{"name": "web", "image": "nginx:1.27"}},
+				},
+			},
+		},
+	}
+	if !reflect.DeepEqual(deployment.Object, expected) {
+		t.Errorf("expected %v, got %v", expected, deployment.Object)
+	}
+
+	clusterRole := unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "rbac.authorization.k8s.io/v1",
+		"kind":       "ClusterRole",
+		"metadata":   map[string]any{"name": "reader"},
+	}}
+	err = transform.Transform(&clusterRole)
+	if err != nil || clusterRole.GetNamespace() != "" {
+		t.Errorf("expected cluster role to stay cluster scoped, got %q %v", clusterRole.GetNamespace(), err)
+	}
+
+	crd := unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "apiextensions.k8s.io/v1",
+		"kind":       "CustomResourceDefinition",
+		"metadata":   map[string]any{"name": "widgets.example.com"},
+		"spec": map[string]any{
+			"group": "example.com",
+			"scope": "Cluster",
+			"names": map[string]any{"kind": "Widget"},
+		},
+	}}
+	widget := unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "example.com/v1",
+		"kind":       "Widget",
+		"metadata":   map[string]any{"name": "a"},
+	}}
+	err = transform.Transform(&widget)
+	if err == nil {
+		t.Errorf("expected an error for an unknown kind without discovery")
+	}
+	err = transform.Transform(&crd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = transform.Transform(&widget)
+	if err != nil || widget.GetNamespace() != "" {
+		t.Errorf("expected widget to use the scope of its definition, got %q %v", widget.GetNamespace(), err)
+	}
+}
+
+func TestDocumentOptionsRequireSplitDocuments(t *testing.T) {
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: a\n"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := ExpandedContentHandlerFunc(func(ec *ExpandedContent) error { return nil })
+	testCases := []FileSetDef{
+		{GlobPaths: []string{dir}, Transform: &TransformOptions{Namespace: "example"}},
+		{GlobPaths: []string{dir}, Cue: &CueOptions{Schema: "schema.cue"}},
+	}
+	for i, f := range testCases {
+		err := f.ExpandContent(handler)
+		if err == nil {
+			t.Errorf("case %d: expected an error when documents are not split", i)
+		}
+		f.SplitYamlDocs = true
+		if f.Cue != nil {
+			continue
+		}
+		err = f.ExpandContent(handler)
+		if err != nil {
+			t.Errorf("case %d: unexpected error when documents are split: %v", i, err)
+		}
+	}
+}
+
+func TestTransformNamespacedDiscovery(t *testing.T) {
+	ipAddress := func() unstructured.Unstructured {
+		return unstructured.Unstructured{Object: map[string]any{
+			"apiVersion": "networking.k8s.io/v1",
+			"kind":       "IPAddress",
+			"metadata":   map[string]any{"name": "10.0.0.1"},
+		}}
+	}
+	testCases := []struct {
+		name         string
+		isNamespaced func(apiVersion, kind string) (bool, error)
+		u            unstructured.Unstructured
+		expected     string
+	}{
+		{"table without discovery", nil, ipAddress(), ""},
+		{"table when discovery fails", func(string, string) (bool, error) { return false, fmt.Errorf("no cluster") }, ipAddress(), ""},
+		{"discovery before the table", func(string, string) (bool, error) { return false, nil }, unstructured.Unstructured{Object: map[string]any{
+			"apiVersion": "networking.k8s.io/v1alpha1",
+			"kind":       "ClusterCIDR",
+			"metadata":   map[string]any{"name": "pods"},
+		}}, ""},
+		{"discovery of a namespaced kind", func(string, string) (bool, error) { return true, nil }, ipAddress(), "example"},
+	}
+	for _, tc := range testCases {
+		transform := &TransformOptions{Namespace: "example", IsNamespaced: tc.isNamespaced}
+		err := transform.Transform(&tc.u)
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		if tc.u.GetNamespace() != tc.expected {
+			t.Errorf("%s: expected namespace %q, got %q", tc.name, tc.expected, tc.u.GetNamespace())
+		}
+	}
+}
+
+func TestTransformDocumentUnchanged(t *testing.T) {
+	content := []byte("# a cluster scoped object\nkind: ClusterRole\napiVersion: rbac.authorization.k8s.io/v1\nmetadata:\n  name: reader # keep\n")
+	transform := &TransformOptions{Namespace: "example"}
+	result, err := transform.TransformDocument(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(result) != string(content) {
+		t.Errorf("expected an unchanged document to keep its text, got %q", result)
+	}
+	transform.Labels = map[string]string{"team": "web"}
+	result, err = transform.TransformDocument(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(result), "team: web") {
+		t.Errorf("expected the label to be added, got %q", result)
+	}
+}
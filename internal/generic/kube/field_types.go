@@ -0,0 +1,219 @@
+package kube
+
+import (
+	"strings"
+
+	runtimeschema "k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// FieldType is the type the api server expects for a field of a manifest.
+type FieldType int
+
+const (
+	FieldTypeUnknown FieldType = iota
+	FieldTypeString
+	FieldTypeInteger
+	FieldTypeNumber
+	FieldTypeBoolean
+	FieldTypeIntOrString
+)
+
+func (t FieldType) String() string {
+	switch t {
+	case FieldTypeString:
+		return "string"
+	case FieldTypeInteger:
+		return "integer"
+	case FieldTypeNumber:
+		return "number"
+	case FieldTypeBoolean:
+		return "boolean"
+	case FieldTypeIntOrString:
+		return "int-or-string"
+	default:
+		return "unknown"
+	}
+}
+
+// ListItem is the path element used for the items of a list.
+const ListItem = "[]"
+
+// FieldTypeResolver tells the type of the field at path in a manifest of the given kind. Path
+// holds object keys and map keys, with ListItem for the items of a list.
+type FieldTypeResolver interface {
+	FieldType(gvk runtimeschema.GroupVersionKind, path []string) FieldType
+}
+
+type FieldTypeResolverFunc func(gvk runtimeschema.GroupVersionKind, path []string) FieldType
+
+func (f FieldTypeResolverFunc) FieldType(gvk runtimeschema.GroupVersionKind, path []string) FieldType {
+	return f(gvk, path)
+}
+
+// ChainFieldTypes returns the first type which is not unknown.
+func ChainFieldTypes(resolvers ...FieldTypeResolver) FieldTypeResolver {
+	return FieldTypeResolverFunc(func(gvk runtimeschema.GroupVersionKind, path []string) FieldType {
+		for _, resolver := range resolvers {
+			if resolver == nil {
+				continue
+			}
+			t := resolver.FieldType(gvk, path)
+			if t != FieldTypeUnknown {
+				return t
+			}
+		}
+		return FieldTypeUnknown
+	})
+}
+
+type fieldTypeRule struct {
+	group     string
+	kind      string
+	pattern   []string
+	fieldType FieldType
+}
+
+// fieldTypeTable matches paths against dot separated patterns, where "*" matches one element
+// and "**" matches any number of elements. A group or kind of "*" matches any.
+type fieldTypeTable []fieldTypeRule
+
+func (table fieldTypeTable) FieldType(gvk runtimeschema.GroupVersionKind, path []string) FieldType {
+	for _, rule := range table {
+		if rule.group != "*" && rule.group != gvk.Group {
+			continue
+		}
+		if rule.kind != "*" && rule.kind != gvk.Kind {
+			continue
+		}
+		if matchFieldPattern(rule.pattern, path) {
+			return rule.fieldType
+		}
+	}
+	return FieldTypeUnknown
+}
+
+func matchFieldPattern(pattern, path []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(path); i++ {
+				if matchFieldPattern(pattern[1:], path[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(path) == 0 || (pattern[0] != "*" && pattern[0] != path[0]) {
+			return false
+		}
+		pattern = pattern[1:]
+		path = path[1:]
+	}
+	return len(path) == 0
+}
+
+func (table *fieldTypeTable) add(group, kind string, fieldType FieldType, patterns ...string) {
+	for _, pattern := range patterns {
+		*table = append(*table, fieldTypeRule{
+			group:     group,
+			kind:      kind,
+			pattern:   strings.Split(pattern, "."),
+			fieldType: fieldType,
+		})
+	}
+}
+
+// BuiltinFieldTypes knows the fields of the core kinds which are most often given values that
+// look like another type, such as labels, env values and ports.
+var BuiltinFieldTypes FieldTypeResolver = newBuiltinFieldTypes()
+
+func newBuiltinFieldTypes() fieldTypeTable {
+	table := fieldTypeTable{}
+
+	table.add("*", "*", FieldTypeString,
+		"apiVersion",
+		"kind",
+		"metadata.name",
+		"metadata.namespace",
+		"metadata.generateName",
+		"**.metadata.labels.*",
+		"**.metadata.annotations.*",
+		"**.selector.matchLabels.*",
+		"**.nodeSelector.*",
+	)
+
+	for _, containers := range []string{"containers", "initContainers", "ephemeralContainers"} {
+		prefix := "**." + containers + ".[]."
+		table.add("*", "*", FieldTypeString,
+			prefix+"name",
+			prefix+"image",
+			prefix+"args.[]",
+			prefix+"command.[]",
+			prefix+"env.[].name",
+			prefix+"env.[].value",
+			prefix+"resources.limits.*",
+			prefix+"resources.requests.*",
+		)
+		table.add("*", "*", FieldTypeInteger,
+			prefix+"ports.[].containerPort",
+			prefix+"ports.[].hostPort",
+		)
+		for _, probe := range []string{"livenessProbe", "readinessProbe", "startupProbe"} {
+			table.add("*", "*", FieldTypeIntOrString,
+				prefix+probe+".httpGet.port",
+				prefix+probe+".tcpSocket.port",
+			)
+			table.add("*", "*", FieldTypeInteger,
+				prefix+probe+".grpc.port",
+				prefix+probe+".initialDelaySeconds",
+				prefix+probe+".periodSeconds",
+				prefix+probe+".timeoutSeconds",
+				prefix+probe+".successThreshold",
+				prefix+probe+".failureThreshold",
+			)
+		}
+	}
+	table.add("*", "*", FieldTypeInteger,
+		"**.securityContext.runAsUser",
+		"**.securityContext.runAsGroup",
+		"**.securityContext.fsGroup",
+		"**.terminationGracePeriodSeconds",
+		"**.activeDeadlineSeconds",
+		"**.volumes.[].configMap.defaultMode",
+		"**.volumes.[].secret.defaultMode",
+	)
+	table.add("*", "*", FieldTypeBoolean,
+		"**.securityContext.runAsNonRoot",
+		"**.securityContext.privileged",
+		"**.securityContext.readOnlyRootFilesystem",
+		"**.securityContext.allowPrivilegeEscalation",
+		"**.hostNetwork",
+		"**.automountServiceAccountToken",
+	)
+
+	table.add("", "ConfigMap", FieldTypeString, "data.*", "binaryData.*")
+	table.add("", "Secret", FieldTypeString, "data.*", "stringData.*", "type")
+	table.add("", "Service", FieldTypeInteger, "spec.ports.[].port", "spec.ports.[].nodePort")
+	table.add("", "Service", FieldTypeIntOrString, "spec.ports.[].targetPort")
+	table.add("", "Service", FieldTypeString, "spec.selector.*")
+
+	table.add("apps", "*", FieldTypeInteger, "spec.replicas", "spec.minReadySeconds", "spec.revisionHistoryLimit")
+	table.add("batch", "Job", FieldTypeInteger,
+		"spec.backoffLimit",
+		"spec.completions",
+		"spec.parallelism",
+		"spec.ttlSecondsAfterFinished",
+	)
+	table.add("batch", "CronJob", FieldTypeString, "spec.schedule", "spec.timeZone")
+	table.add("batch", "CronJob", FieldTypeInteger,
+		"spec.jobTemplate.spec.backoffLimit",
+		"spec.jobTemplate.spec.completions",
+		"spec.jobTemplate.spec.parallelism",
+		"spec.jobTemplate.spec.ttlSecondsAfterFinished",
+		"spec.successfulJobsHistoryLimit",
+		"spec.failedJobsHistoryLimit",
+	)
+	table.add("networking.k8s.io", "Ingress", FieldTypeInteger, "**.service.port.number")
+	table.add("autoscaling", "HorizontalPodAutoscaler", FieldTypeInteger, "spec.minReplicas", "spec.maxReplicas")
+
+	return table
+}
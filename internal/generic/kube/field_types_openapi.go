@@ -0,0 +1,134 @@
+package kube
+
+import (
+	"strings"
+	"sync"
+
+	runtimeschema "k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/openapi"
+	"k8s.io/client-go/openapi3"
+)
+
+// openAPISpecSource is the part of openapi3.Root which is used, so tests can supply a spec.
+type openAPISpecSource interface {
+	GVSpecAsMap(gv runtimeschema.GroupVersion) (map[string]interface{}, error)
+}
+
+// OpenAPIFieldTypes looks up field types in the OpenAPI v3 schema published by the cluster. Each
+// group version is fetched once. When it can not be fetched every field of it is unknown.
+type OpenAPIFieldTypes struct {
+	lock    sync.Mutex
+	source  openAPISpecSource
+	schemas map[runtimeschema.GroupVersion]map[string]any
+	kinds   map[runtimeschema.GroupVersionKind]map[string]any
+}
+
+func NewOpenAPIFieldTypes(client openapi.Client) *OpenAPIFieldTypes {
+	return newOpenAPIFieldTypes(openapi3.NewRoot(client))
+}
+
+func newOpenAPIFieldTypes(source openAPISpecSource) *OpenAPIFieldTypes {
+	return &OpenAPIFieldTypes{
+		source:  source,
+		schemas: make(map[runtimeschema.GroupVersion]map[string]any),
+		kinds:   make(map[runtimeschema.GroupVersionKind]map[string]any),
+	}
+}
+
+func (o *OpenAPIFieldTypes) FieldType(gvk runtimeschema.GroupVersionKind, path []string) FieldType {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	schemas := o.componentSchemas(gvk.GroupVersion())
+	s, found := o.kinds[gvk]
+	if !found {
+		s = findKindSchema(schemas, gvk)
+		o.kinds[gvk] = s
+	}
+	for _, element := range path {
+		s = resolveSchemaRef(schemas, s)
+		if s == nil || s["x-kubernetes-preserve-unknown-fields"] == true {
+			return FieldTypeUnknown
+		}
+		if element == ListItem {
+			s, _ = s["items"].(map[string]any)
+			continue
+		}
+		properties, _ := s["properties"].(map[string]any)
+		if property, ok := properties[element].(map[string]any); ok {
+			s = property
+			continue
+		}
+		s, _ = s["additionalProperties"].(map[string]any)
+	}
+	return schemaFieldType(resolveSchemaRef(schemas, s))
+}
+
+// componentSchemas must be called with the lock held.
+func (o *OpenAPIFieldTypes) componentSchemas(gv runtimeschema.GroupVersion) map[string]any {
+	schemas, found := o.schemas[gv]
+	if found {
+		return schemas
+	}
+	spec, err := o.source.GVSpecAsMap(gv)
+	if err == nil {
+		components, _ := spec["components"].(map[string]any)
+		schemas, _ = components["schemas"].(map[string]any)
+	}
+	o.schemas[gv] = schemas
+	return schemas
+}
+
+func findKindSchema(schemas map[string]any, gvk runtimeschema.GroupVersionKind) map[string]any {
+	for _, s := range schemas {
+		s, _ := s.(map[string]any)
+		kinds, _ := s["x-kubernetes-group-version-kind"].([]any)
+		for _, kind := range kinds {
+			kind, _ := kind.(map[string]any)
+			if kind["group"] == gvk.Group && kind["version"] == gvk.Version && kind["kind"] == gvk.Kind {
+				return s
+			}
+		}
+	}
+	return nil
+}
+
+// resolveSchemaRef follows $ref, including the single allOf which is used to give a $ref a
+// description or default.
+func resolveSchemaRef(schemas map[string]any, s map[string]any) map[string]any {
+	for range 32 {
+		if s == nil {
+			return nil
+		}
+		if allOf, ok := s["allOf"].([]any); ok && len(allOf) == 1 && s["type"] == nil && s["properties"] == nil {
+			s, _ = allOf[0].(map[string]any)
+			continue
+		}
+		ref, ok := s["$ref"].(string)
+		if !ok {
+			return s
+		}
+		s, _ = schemas[strings.TrimPrefix(ref, "#/components/schemas/")].(map[string]any)
+	}
+	return nil
+}
+
+func schemaFieldType(s map[string]any) FieldType {
+	if s == nil {
+		return FieldTypeUnknown
+	}
+	if s["x-kubernetes-int-or-string"] == true || s["format"] == "int-or-string" {
+		return FieldTypeIntOrString
+	}
+	switch s["type"] {
+	case "string":
+		return FieldTypeString
+	case "integer":
+		return FieldTypeInteger
+	case "number":
+		return FieldTypeNumber
+	case "boolean":
+		return FieldTypeBoolean
+	}
+	return FieldTypeUnknown
+}
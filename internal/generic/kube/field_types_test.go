@@ -0,0 +1,121 @@
+package kube
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	runtimeschema "k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestBuiltinFieldTypes(t *testing.T) {
+	deployment := runtimeschema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}
+	configMap := runtimeschema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}
+	service := runtimeschema.GroupVersionKind{Version: "v1", Kind: "Service"}
+	tests := []struct {
+		gvk      runtimeschema.GroupVersionKind
+		path     []string
+		expected FieldType
+	}{
+		{configMap, []string{"metadata", "labels", "enabled"}, FieldTypeString},
+		{configMap, []string{"metadata", "annotations", "app.kubernetes.io/version"}, FieldTypeString},
+		{configMap, []string{"data", "port"}, FieldTypeString},
+		{configMap, []string{"spec", "replicas"}, FieldTypeUnknown},
+		{deployment, []string{"spec", "replicas"}, FieldTypeInteger},
+		{deployment, []string{"spec", "template", "metadata", "labels", "tier"}, FieldTypeString},
+		{deployment, []string{"spec", "template", "spec", "containers", ListItem, "env", ListItem, "value"}, FieldTypeString},
+		{deployment, []string{"spec", "template", "spec", "containers", ListItem, "ports", ListItem, "containerPort"}, FieldTypeInteger},
+		{deployment, []string{"spec", "template", "spec", "containers", ListItem, "args", ListItem}, FieldTypeString},
+		{deployment, []string{"spec", "template", "spec", "containers", ListItem, "env", ListItem, "valueFrom"}, FieldTypeUnknown},
+		{service, []string{"spec", "ports", ListItem, "targetPort"}, FieldTypeIntOrString},
+		{service, []string{"spec", "ports", ListItem, "port"}, FieldTypeInteger},
+	}
+	for _, test := range tests {
+		actual := BuiltinFieldTypes.FieldType(test.gvk, test.path)
+		if actual != test.expected {
+			t.Errorf("%s %v: expected %s, got %s", test.gvk.Kind, test.path, test.expected, actual)
+		}
+	}
+}
+
+type testSpecSource map[runtimeschema.GroupVersion]string
+
+func (s testSpecSource) GVSpecAsMap(gv runtimeschema.GroupVersion) (map[string]interface{}, error) {
+	text, found := s[gv]
+	if !found {
+		return nil, fmt.Errorf("no spec for %s", gv)
+	}
+	var spec map[string]interface{}
+	err := json.Unmarshal([]byte(text), &spec)
+	return spec, err
+}
+
+const testWidgetSpec = `{
+  "components": {
+    "schemas": {
+      "com.example.v1.Widget": {
+        "x-kubernetes-group-version-kind": [{"group": "example.com", "version": "v1", "kind": "Widget"}],
+        "properties": {
+          "metadata": {"allOf": [{"$ref": "#/components/schemas/ObjectMeta"}], "default": {}},
+          "spec": {
+            "properties": {
+              "size": {"type": "integer"},
+              "ratio": {"type": "number"},
+              "enabled": {"type": "boolean"},
+              "port": {"x-kubernetes-int-or-string": true},
+              "settings": {"type": "object", "additionalProperties": {"type": "string"}},
+              "items": {"type": "array", "items": {"$ref": "#/components/schemas/Item"}},
+              "extra": {"type": "object", "x-kubernetes-preserve-unknown-fields": true}
+            }
+          }
+        }
+      },
+      "ObjectMeta": {
+        "properties": {
+          "labels": {"type": "object", "additionalProperties": {"type": "string", "default": ""}}
+        }
+      },
+      "Item": {
+        "properties": {
+          "name": {"type": "string"},
+          "count": {"type": "integer"}
+        }
+      }
+    }
+  }
+}`
+
+func TestOpenAPIFieldTypes(t *testing.T) {
+	widget := runtimeschema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Widget"}
+	missing := runtimeschema.GroupVersionKind{Group: "missing.com", Version: "v1", Kind: "Widget"}
+	resolver := newOpenAPIFieldTypes(testSpecSource{widget.GroupVersion(): testWidgetSpec})
+	tests := []struct {
+		gvk      runtimeschema.GroupVersionKind
+		path     []string
+		expected FieldType
+	}{
+		{widget, []string{"spec", "size"}, FieldTypeInteger},
+		{widget, []string{"spec", "ratio"}, FieldTypeNumber},
+		{widget, []string{"spec", "enabled"}, FieldTypeBoolean},
+		{widget, []string{"spec", "port"}, FieldTypeIntOrString},
+		{widget, []string{"spec", "settings", "anything"}, FieldTypeString},
+		{widget, []string{"spec", "items", ListItem, "count"}, FieldTypeInteger},
+		{widget, []string{"spec", "items", ListItem, "name"}, FieldTypeString},
+		{widget, []string{"spec", "extra", "size"}, FieldTypeUnknown},
+		{widget, []string{"spec", "nothing"}, FieldTypeUnknown},
+		{widget, []string{"metadata", "labels", "enabled"}, FieldTypeString},
+		{missing, []string{"spec", "size"}, FieldTypeUnknown},
+	}
+	for _, test := range tests {
+		actual := resolver.FieldType(test.gvk, test.path)
+		if actual != test.expected {
+			t.Errorf("%s %v: expected %s, got %s", test.gvk.Group, test.path, test.expected, actual)
+		}
+	}
+
+	chained := ChainFieldTypes(resolver, BuiltinFieldTypes)
+	actual := chained.FieldType(missing, []string{"metadata", "labels", "enabled"})
+	if actual != FieldTypeString {
+		t.Errorf("expected the built in table to be used when there is no schema, got %s", actual)
+	}
+}
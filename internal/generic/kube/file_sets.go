@@ -1,11 +1,10 @@
 package kube
 
 import (
-	"bufio"
 	"bytes"
 	"fmt"
 	htemplate "html/template"
-	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
@@ -13,9 +12,11 @@ import (
 )
 
 type ExpandedContent struct {
-	Filename string
-	LineNo   int
-	Content  []byte
+	Filename  string
+	LineNo    int
+	Content   []byte
+	Sensitive bool
+	Mode      fs.FileMode
 }
 
 type ExpandedContentHandler interface {
@@ -30,41 +31,74 @@ func (f ExpandedContentHandlerFunc) HandleExpandedContent(content *ExpandedConte
 
 type FileSetDef struct {
 	GlobPaths     []string
+	Excludes      []string
 	Variables     map[string]any
 	TemplateType  string
 	SplitYamlDocs bool
-	//TODO SOPS decode
+	Helm          *HelmOptions
+	Sops          *SopsOptions
+	Jsonnet       *JsonnetOptions
+	Cue           *CueOptions
+	Git           *GitOptions
+	Transform     *TransformOptions
+	// Strict rejects duplicate keys and unknown fields while documents are split, where the
+	// original lines are still known
+	Strict bool
 }
 
-func (f FileSetDef) processDocument(ec *ExpandedContent, handler ExpandedContentHandler) error {
+// expandTemplate applies the template type to the whole content of a file.
+func (f FileSetDef) expandTemplate(ec *ExpandedContent) error {
 	w := &bytes.Buffer{}
 	switch f.TemplateType {
 	case "go/text":
-		t := ttemplate.New("go-text")
+		t := ttemplate.New(ec.Filename)
 		//todo funcs
 		t, err := t.Parse(string(ec.Content))
 		if err != nil {
 			return err
 		}
-		t.Execute(w, f.Variables)
+		err = t.Execute(w, f.Variables)
+		if err != nil {
+			return err
+		}
 		ec.Content = w.Bytes()
 	case "go/html":
-		t := htemplate.New("go-text")
+		t := htemplate.New(ec.Filename)
 		//todo funcs
 		t, err := t.Parse(string(ec.Content))
 		if err != nil {
 			return err
 		}
-		t.Execute(w, f.Variables)
+		err = t.Execute(w, f.Variables)
+		if err != nil {
+			return err
+		}
 		ec.Content = w.Bytes()
 	default:
 		if f.TemplateType != "" {
-			return fmt.Errorf("unknown template type %s\nsupported=go/text,go/html", f.TemplateType)
+			return fmt.Errorf("unknown template type %s\nsupported=go/text,go/html,kustomize,helm,jsonnet,cue", f.TemplateType)
 		}
 		if len(f.Variables) > 0 {
 			return fmt.Errorf("variables are only supported when template_type is set")
 		}
 	}
+	return nil
+}
+
+func (f FileSetDef) processDocument(ec *ExpandedContent, handler ExpandedContentHandler) error {
+	if f.Transform != nil {
+		content, err := f.Transform.TransformDocument(ec.Content)
+		if err != nil {
+			return fmt.Errorf("%s line %d: %w", ec.Filename, ec.LineNo, err)
+		}
+		ec.Content = content
+	}
+	if f.Cue != nil && f.Cue.Schema != "" {
+		err := f.Cue.validateDocument(ec.Content)
+		if err != nil {
+			return fmt.Errorf("%s line %d: %w", ec.Filename, ec.LineNo, err)
+		}
+	}
 	//TODO process the document
 	err := handler.HandleExpandedContent(ec)
 	if err != nil {
@@ -73,85 +107,186 @@ func (f FileSetDef) processDocument(ec *ExpandedContent, handler ExpandedContent
 	return nil
 }
 
-func (f FileSetDef) ExpandContent(handler ExpandedContentHandler) error {
-	for _, globPath := range f.GlobPaths {
-		realPaths, err := filepath.Glob(globPath)
+// processFile expands the template of a file before it is split, so templates may generate or
+// span several documents.
+func (f FileSetDef) processFile(file ExpandedContent, handler ExpandedContentHandler) error {
+	err := f.expandTemplate(&file)
+	if err != nil {
+		return err
+	}
+	if f.SplitYamlDocs && isManifestFile(file.Filename) {
+		return f.splitYamlDocuments(file, handler)
+	}
+	return f.processDocument(&file, handler)
+}
+
+func (f FileSetDef) splitYamlDocuments(file ExpandedContent, handler ExpandedContentHandler) error {
+	documents, err := DecodeYamlStream(file.Filename, file.Content)
+	if err != nil {
+		return err
+	}
+	for _, document := range documents {
+		if f.Strict {
+			err = checkStrictDocument(document)
+			if err != nil {
+				return fmt.Errorf("%s: %w", file.Filename, err)
+			}
+		}
+		ec := ExpandedContent{
+			Filename:  file.Filename,
+			LineNo:    document.LineNo,
+			Content:   document.Content,
+			Sensitive: file.Sensitive,
+			Mode:      file.Mode,
+		}
+		err = f.processDocument(&ec, handler)
 		if err != nil {
 			return err
 		}
-		if len(realPaths) == 0 {
-			return fmt.Errorf("no files found for glob %s", globPath)
+	}
+	return nil
+}
+
+// newFileReader returns a function which decrypts and processes each file once.
+func (f FileSetDef) newFileReader(handler ExpandedContentHandler) func(filename string, mode fs.FileMode, content []byte) error {
+	seen := make(map[string]bool)
+	return func(filename string, mode fs.FileMode, content []byte) error {
+		if seen[filename] {
+			return nil
 		}
+		seen[filename] = true
+		sensitive := false
+		if f.Sops != nil {
+			var err error
+			content, sensitive, err = f.Sops.decrypt(filename, content)
+			if err != nil {
+				return err
+			}
+		}
+		return f.processFile(ExpandedContent{Filename: filename, Content: content, Sensitive: sensitive, Mode: mode}, handler)
+	}
+}
 
-		for _, realPath := range realPaths {
-			stats, err := os.Stat(realPath)
+func (f FileSetDef) ExpandContent(handler ExpandedContentHandler) error {
+	err := f.checkDocumentOptions()
+	if err != nil {
+		return err
+	}
+	if f.Git != nil {
+		return f.readGitFiles(f.newFileReader(handler))
+	}
+	switch f.TemplateType {
+	case "kustomize":
+		return f.expandKustomizations(handler)
+	case "helm":
+		return f.expandHelmCharts(handler)
+	case "jsonnet":
+		return f.expandJsonnet(handler)
+	case "cue":
+		return f.expandCue(handler)
+	}
+	readFile := f.newFileReader(handler)
+	for _, globPath := range f.GlobPaths {
+		if archiveGlob, inner, ok := splitArchivePath(globPath); ok {
+			archives, err := f.globPaths(archiveGlob)
 			if err != nil {
 				return err
 			}
-			if stats.IsDir() {
-				return nil
+			for _, archive := range archives {
+				err = f.readArchiveEntries(archive, inner, func(name string, mode fs.FileMode, content []byte) error {
+					return readFile(archive+archiveSeparator+name, mode, content)
+				})
+				if err != nil {
+					return err
+				}
 			}
-			file, err := os.Open(realPath)
+			continue
+		}
+
+		realPaths, err := f.expandFilePaths(globPath)
+		if err != nil {
+			return err
+		}
+		for _, realPath := range realPaths {
+			info, err := os.Stat(realPath)
 			if err != nil {
 				return err
 			}
-			defer file.Close()
-			content, err := io.ReadAll(file)
+			content, err := os.ReadFile(realPath)
 			if err != nil {
 				return err
 			}
-			ec := ExpandedContent{
-				Filename: realPath,
-				LineNo:   0,
-				Content:  content,
-			}
-			fileExt := strings.ToLower(filepath.Ext(realPath))
-			if (fileExt == ".yaml" || fileExt == ".yml") && f.SplitYamlDocs {
-				buffer := &bytes.Buffer{}
-				r := bytes.NewReader(content)
-				scanner := bufio.NewScanner(r)
-
-				// Scan the file line by line
-				for scanner.Scan() {
-					line := scanner.Text() // Get the current line as a string
-					ec.LineNo++
-					if strings.TrimRight(line, " \t") == "---" {
-						// If we encounter a separator, handle the current content
-						if buffer.Len() > 0 {
-							ec.Content = buffer.Bytes()
-							err = f.processDocument(&ec, handler)
-							if err != nil {
-								return err
-							}
-							buffer.Reset() // Clear the buffer for the next document
-						}
-					} else {
-						buffer.WriteString(line + "\n") // Append the line to the buffer
-					}
-				}
-				if buffer.Len() > 0 {
-					// Handle the last document if there's any content left in the buffer
-					ec.Content = buffer.Bytes()
-					err = f.processDocument(&ec, handler)
-					if err != nil {
-						return err
-					}
-					buffer.Reset()
-				}
-				if err := scanner.Err(); err != nil {
-					return err
-				}
-			} else {
-				err = f.processDocument(&ec, handler)
-				if err != nil {
-					return err
-				}
+			err = readFile(realPath, info.Mode(), content)
+			if err != nil {
+				return err
 			}
 		}
 	}
 	return nil
 }
 
+// FilterPath is the path of an expanded file which document filters match against. It is the
+// same as the key used by kube_files, relative to the working directory, or the filename when the
+// file is outside of it.
+func (f FileSetDef) FilterPath(filename string) string {
+	rel, err := f.RelativePath("", filename)
+	if err != nil {
+		return filepath.ToSlash(filename)
+	}
+	return rel
+}
+
+// RelativePath returns the slash separated path of an expanded file relative to root. Files read
+// from git use their path within the repository and archive entries keep the archive separator.
+func (f FileSetDef) RelativePath(root, filename string) (string, error) {
+	if f.Git != nil {
+		_, name, found := strings.Cut(strings.TrimPrefix(filename, f.Git.Repository+"@"), ":")
+		if found {
+			return name, nil
+		}
+		return filename, nil
+	}
+	realPath, entry, isEntry := splitArchivePath(filename)
+	if !isEntry {
+		realPath = filename
+	}
+	absRoot, err := filepath.Abs(FirstNonNullString(root, "."))
+	if err != nil {
+		return "", err
+	}
+	absPath, err := filepath.Abs(realPath)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(absRoot, absPath)
+	if err != nil {
+		return "", err
+	}
+	if rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is outside of %s", realPath, absRoot)
+	}
+	rel = filepath.ToSlash(rel)
+	if isEntry {
+		rel += archiveSeparator + entry
+	}
+	return rel, nil
+}
+
+// checkDocumentOptions rejects the options which apply to each manifest document when the files
+// are not split into documents, rather than silently ignoring them.
+func (f FileSetDef) checkDocumentOptions() error {
+	if f.SplitYamlDocs {
+		return nil
+	}
+	if f.Transform != nil {
+		return fmt.Errorf("transform is only supported when files are split into manifest documents")
+	}
+	if f.Cue != nil && f.Cue.Schema != "" {
+		return fmt.Errorf("cue schema is only supported when files are split into manifest documents")
+	}
+	return nil
+}
+
 type FileSetDefs []*FileSetDef
 
 func (f FileSetDefs) ExpandContent(handler ExpandedContentHandler) error {
@@ -0,0 +1,176 @@
+package kube
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+const archiveSeparator = "//"
+
+var archiveExtensions = []string{".tar.gz", ".tgz", ".tar", ".zip"}
+
+func isArchive(filename string) bool {
+	lower := strings.ToLower(filename)
+	for _, ext := range archiveExtensions {
+		if strings.HasSuffix(lower, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitArchivePath splits a path like `bundle.tgz//deploy/*.yaml` into the archive and the
+// pattern of the entries within it.
+func splitArchivePath(globPath string) (archive string, inner string, ok bool) {
+	offset := 0
+	for {
+		i := strings.Index(globPath[offset:], archiveSeparator)
+		if i < 0 {
+			return "", "", false
+		}
+		i += offset
+		if isArchive(globPath[:i]) {
+			return globPath[:i], globPath[i+len(archiveSeparator):], true
+		}
+		offset = i + len(archiveSeparator)
+	}
+}
+
+type archiveEntryHandler func(name string, mode fs.FileMode, content []byte) error
+
+func (f FileSetDef) archiveEntryMatches(archive, inner, name string) (bool, error) {
+	name = strings.TrimPrefix(path.Clean(name), "./")
+	if inner == "" {
+		return isManifestFile(name), nil
+	}
+	matched, err := doublestar.Match(inner, name)
+	if err != nil {
+		return false, fmt.Errorf("invalid glob %s: %w", inner, err)
+	}
+	if !matched && strings.HasPrefix(name, strings.TrimSuffix(inner, "/")+"/") {
+		// the pattern names a directory within the archive
+		matched = !f.SplitYamlDocs || isManifestFile(name)
+	}
+	if !matched {
+		return false, nil
+	}
+	excluded, err := f.isExcluded(archive + archiveSeparator + name)
+	return !excluded, err
+}
+
+func (f FileSetDef) readTarEntries(archive, inner string, r io.Reader, handler archiveEntryHandler) (int, error) {
+	count := 0
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return count, nil
+		}
+		if err != nil {
+			return count, fmt.Errorf("error reading %s: %w", archive, err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		matched, err := f.archiveEntryMatches(archive, inner, header.Name)
+		if err != nil {
+			return count, err
+		}
+		if !matched {
+			continue
+		}
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			return count, fmt.Errorf("error reading %s from %s: %w", header.Name, archive, err)
+		}
+		count++
+		err = handler(path.Clean(header.Name), header.FileInfo().Mode(), content)
+		if err != nil {
+			return count, err
+		}
+	}
+}
+
+func (f FileSetDef) readZipEntries(archive, inner string, handler archiveEntryHandler) (int, error) {
+	zr, err := zip.OpenReader(archive)
+	if err != nil {
+		return 0, err
+	}
+	defer zr.Close()
+	files := append([]*zip.File{}, zr.File...)
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+	count := 0
+	for _, file := range files {
+		if file.FileInfo().IsDir() {
+			continue
+		}
+		matched, err := f.archiveEntryMatches(archive, inner, file.Name)
+		if err != nil {
+			return count, err
+		}
+		if !matched {
+			continue
+		}
+		r, err := file.Open()
+		if err != nil {
+			return count, err
+		}
+		content, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			return count, fmt.Errorf("error reading %s from %s: %w", file.Name, archive, err)
+		}
+		count++
+		err = handler(path.Clean(file.Name), file.Mode(), content)
+		if err != nil {
+			return count, err
+		}
+	}
+	return count, nil
+}
+
+// readArchiveEntries streams the entries of a tar, tar.gz or zip archive which match the inner
+// pattern to the handler without unpacking the archive to disk. Tar entries are visited in archive
+// order and zip entries in lexical order.
+func (f FileSetDef) readArchiveEntries(archive, inner string, handler archiveEntryHandler) error {
+	var count int
+	var err error
+	lower := strings.ToLower(archive)
+	if strings.HasSuffix(lower, ".zip") {
+		count, err = f.readZipEntries(archive, inner, handler)
+	} else {
+		var file *os.File
+		file, err = os.Open(archive)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		var r io.Reader = file
+		if strings.HasSuffix(lower, ".gz") || strings.HasSuffix(lower, ".tgz") {
+			gz, err := gzip.NewReader(file)
+			if err != nil {
+				return fmt.Errorf("error reading %s: %w", archive, err)
+			}
+			defer gz.Close()
+			r = gz
+		}
+		count, err = f.readTarEntries(archive, inner, r, handler)
+	}
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return fmt.Errorf("no files found for %s in %s", FirstNonNullString(inner, "*"), archive)
+	}
+	return nil
+}
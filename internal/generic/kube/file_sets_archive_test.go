@@ -0,0 +1,115 @@
+package kube
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+var testArchiveEntries = []struct {
+	name    string
+	content string
+}{
+	{"bundle/deploy/a.yaml", "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: a\n---\napiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: b\n"},
+	{"bundle/deploy/readme.md", "not a manifest"},
+	{"bundle/crds/c.yaml", "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: c\n"},
+}
+
+func writeTestTarGz(t *testing.T, filename string) {
+	file, err := os.Create(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	gz := gzip.NewWriter(file)
+	tw := tar.NewWriter(gz)
+	for _, entry := range testArchiveEntries {
+		err = tw.WriteHeader(&tar.Header{Name: entry.name, Mode: 0o644, Size: int64(len(entry.content)), Typeflag: tar.TypeReg})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = tw.Write([]byte(entry.content))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	tw.Close()
+	gz.Close()
+}
+
+func writeTestZip(t *testing.T, filename string) {
+	file, err := os.Create(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	zw := zip.NewWriter(file)
+	for _, entry := range testArchiveEntries {
+		w, err := zw.Create(entry.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = w.Write([]byte(entry.content))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	zw.Close()
+}
+
+func TestSplitArchivePath(t *testing.T) {
+	testCases := []struct {
+		input   string
+		archive string
+		inner   string
+		ok      bool
+	}{
+		{"/a//b/bundle.tgz//deploy/*.yaml", "/a//b/bundle.tgz", "deploy/*.yaml", true},
+		{"bundle.zip//", "bundle.zip", "", true},
+		{"/manifests//*.yaml", "", "", false},
+	}
+	for _, tc := range testCases {
+		archive, inner, ok := splitArchivePath(tc.input)
+		if archive != tc.archive || inner != tc.inner || ok != tc.ok {
+			t.Errorf("splitArchivePath(%s) = %s, %s, %v, expected %s, %s, %v", tc.input, archive, inner, ok, tc.archive, tc.inner, tc.ok)
+		}
+	}
+}
+
+func TestExpandArchives(t *testing.T) {
+	dir := t.TempDir()
+	writeTestTarGz(t, filepath.Join(dir, "bundle.tgz"))
+	writeTestZip(t, filepath.Join(dir, "bundle.zip"))
+
+	testCases := []struct {
+		path     string
+		expected []string
+	}{
+		{"bundle.tgz//bundle/deploy/*.yaml", []string{"a", "b"}},
+		{"bundle.tgz//bundle", []string{"a", "b", "c"}},
+		{"bundle.zip//**/*.yaml", []string{"c", "a", "b"}},
+	}
+	for _, tc := range testCases {
+		f := FileSetDef{GlobPaths: []string{dir + "/" + tc.path}, SplitYamlDocs: true}
+		var names []string
+		err := f.ExpandContent(ExpandedContentHandlerFunc(func(ec *ExpandedContent) error {
+			u, err := ParseSingleYamlManifest(string(ec.Content))
+			if err != nil {
+				return err
+			}
+			names = append(names, u.GetName())
+			return nil
+		}))
+		if err != nil {
+			t.Errorf("%s: %v", tc.path, err)
+			continue
+		}
+		if !reflect.DeepEqual(names, tc.expected) {
+			t.Errorf("%s: got %v, expected %v", tc.path, names, tc.expected)
+		}
+	}
+}
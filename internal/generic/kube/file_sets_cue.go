@@ -0,0 +1,137 @@
+package kube
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"cuelang.org/go/cue"
+	"cuelang.org/go/cue/cuecontext"
+	"cuelang.org/go/cue/errors"
+	"cuelang.org/go/cue/load"
+	"sigs.k8s.io/yaml"
+)
+
+type CueOptions struct {
+	// Expression selects the objects to emit from a package ( like `cue export -e` )
+	Expression string
+	// Schema is a cue file or package directory which every document must unify with
+	Schema           string
+	SchemaExpression string
+
+	once      sync.Once
+	schema    cue.Value
+	schemaErr error
+}
+
+func cueError(err error) error {
+	return fmt.Errorf("%s", errors.Details(err, nil))
+}
+
+func loadCueValue(ctx *cue.Context, path string, tags []string) (cue.Value, error) {
+	stats, err := os.Stat(path)
+	if err != nil {
+		return cue.Value{}, err
+	}
+	config := &load.Config{Tags: tags}
+	args := []string{"."}
+	if stats.IsDir() {
+		config.Dir = path
+	} else {
+		config.Dir = filepath.Dir(path)
+		args = []string{filepath.Base(path)}
+	}
+	instances := load.Instances(args, config)
+	if len(instances) != 1 {
+		return cue.Value{}, fmt.Errorf("expected a single cue instance in %s, found %d", path, len(instances))
+	}
+	if instances[0].Err != nil {
+		return cue.Value{}, cueError(instances[0].Err)
+	}
+	value := ctx.BuildInstance(instances[0])
+	if value.Err() != nil {
+		return cue.Value{}, cueError(value.Err())
+	}
+	return value, nil
+}
+
+func (o *CueOptions) compiledSchema() (cue.Value, error) {
+	o.once.Do(func() {
+		o.schema, o.schemaErr = loadCueValue(cuecontext.New(), o.Schema, nil)
+		if o.schemaErr == nil && o.SchemaExpression != "" {
+			o.schema = o.schema.LookupPath(cue.ParsePath(o.SchemaExpression))
+			if !o.schema.Exists() {
+				o.schemaErr = fmt.Errorf("schema expression %s not found in %s", o.SchemaExpression, o.Schema)
+			}
+		}
+	})
+	return o.schema, o.schemaErr
+}
+
+// validateDocument unifies a yaml or json document with the schema and requires the result to be
+// concrete.
+func (o *CueOptions) validateDocument(content []byte) error {
+	schema, err := o.compiledSchema()
+	if err != nil {
+		return err
+	}
+	var document any
+	err = yaml.Unmarshal(content, &document)
+	if err != nil {
+		return err
+	}
+	value := schema.Unify(schema.Context().Encode(document))
+	err = value.Validate(cue.Concrete(true))
+	if err != nil {
+		return fmt.Errorf("schema validation failed: %w", cueError(err))
+	}
+	return nil
+}
+
+func (f FileSetDef) renderCuePackage(path string) (any, error) {
+	var tags []string
+	for key, value := range f.Variables {
+		tags = append(tags, fmt.Sprintf("%s=%v", key, value))
+	}
+	value, err := loadCueValue(cuecontext.New(), path, tags)
+	if err != nil {
+		return nil, err
+	}
+	if f.Cue != nil && f.Cue.Expression != "" {
+		value = value.LookupPath(cue.ParsePath(f.Cue.Expression))
+		if !value.Exists() {
+			return nil, fmt.Errorf("expression %s not found", f.Cue.Expression)
+		}
+	}
+	err = value.Validate(cue.Concrete(true))
+	if err != nil {
+		return nil, cueError(err)
+	}
+	var result any
+	err = value.Decode(&result)
+	if err != nil {
+		return nil, cueError(err)
+	}
+	return result, nil
+}
+
+func (f FileSetDef) expandCue(handler ExpandedContentHandler) error {
+	for _, globPath := range f.GlobPaths {
+		realPaths, err := f.globPaths(globPath)
+		if err != nil {
+			return err
+		}
+		for _, realPath := range realPaths {
+			value, err := f.renderCuePackage(realPath)
+			if err != nil {
+				return fmt.Errorf("cue export %s: %w", realPath, err)
+			}
+			err = f.processGeneratedValue(realPath, value, handler)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package kube
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExpandCue(t *testing.T) {
+	dir := t.TempDir()
+	pkg := `package deploy
+
+appName: string @tag(name)
+objects: [{
+	apiVersion: "v1"
+	kind:       "ConfigMap"
+	metadata: name: appName
+}]
+`
+	err := os.WriteFile(filepath.Join(dir, "deploy.cue"), []byte(pkg), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f := FileSetDef{
+		GlobPaths:     []string{dir},
+		TemplateType:  "cue",
+		Variables:     map[string]any{"name": "first"},
+		SplitYamlDocs: true,
+		Cue:           &CueOptions{Expression: "objects"},
+	}
+	var names []string
+	err = f.ExpandContent(ExpandedContentHandlerFunc(func(ec *ExpandedContent) error {
+		u, err := ParseSingleYamlManifest(string(ec.Content))
+		if err != nil {
+			return err
+		}
+		names = append(names, u.GetName())
+		return nil
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(names, []string{"first"}) {
+		t.Errorf("unexpected documents %v", names)
+	}
+}
+
+func TestCueSchemaValidation(t *testing.T) {
+	dir := t.TempDir()
+	schema := `#Manifest: {
+	apiVersion: string
+	kind:       string
+	metadata: name: =~"^[a-z-]+$"
+	...
+}
+`
+	schemaPath := filepath.Join(dir, "schema.cue")
+	err := os.WriteFile(schemaPath, []byte(schema), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifests := "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: good\n---\napiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: Bad_Name\n"
+	err = os.WriteFile(filepath.Join(dir, "manifests.yaml"), []byte(manifests), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f := FileSetDef{
+		GlobPaths:     []string{filepath.Join(dir, "*.yaml")},
+		SplitYamlDocs: true,
+		Cue:           &CueOptions{Schema: schemaPath, SchemaExpression: "#Manifest"},
+	}
+	count := 0
+	err = f.ExpandContent(ExpandedContentHandlerFunc(func(ec *ExpandedContent) error {
+		count++
+		return nil
+	}))
+	if err == nil || !strings.Contains(err.Error(), "schema validation failed") {
+		t.Fatalf("expected schema validation error, got %v", err)
+	}
+	if count != 1 {
+		t.Errorf("expected 1 valid document before the error, got %d", count)
+	}
+}
@@ -0,0 +1,105 @@
+package kube
+
+import (
+	"fmt"
+	"io/fs"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+type GitOptions struct {
+	Repository string
+	Ref        string
+}
+
+// resolveTree returns the tree of the commit, tag or branch named by Ref along with the hash of
+// the commit it resolved to.
+func (o *GitOptions) resolveTree() (*object.Tree, string, error) {
+	repoPath, err := ExpandEnv(o.Repository)
+	if err != nil {
+		return nil, "", err
+	}
+	repo, err := git.PlainOpenWithOptions(repoPath, &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return nil, "", fmt.Errorf("error opening git repository %s: %w", o.Repository, err)
+	}
+	ref := FirstNonNullString(o.Ref, "HEAD")
+	hash, err := repo.ResolveRevision(plumbing.Revision(ref))
+	if err != nil {
+		return nil, "", fmt.Errorf("error resolving %s in %s: %w", ref, o.Repository, err)
+	}
+	commit, err := repo.CommitObject(*hash)
+	if err != nil {
+		return nil, "", fmt.Errorf("error reading commit %s in %s: %w", hash, o.Repository, err)
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, "", err
+	}
+	return tree, hash.String(), nil
+}
+
+func (f FileSetDef) gitFileMatches(globPath, name string) (bool, error) {
+	globPath = strings.Trim(globPath, "/")
+	matched, err := doublestar.Match(globPath, name)
+	if err != nil {
+		return false, fmt.Errorf("invalid glob %s: %w", globPath, err)
+	}
+	if !matched && (globPath == "" || globPath == "." || strings.HasPrefix(name, globPath+"/")) {
+		// the pattern names a directory within the repository
+		matched = !f.SplitYamlDocs || isManifestFile(name)
+	}
+	if !matched {
+		return false, nil
+	}
+	excluded, err := f.isExcluded(name)
+	return !excluded, err
+}
+
+// readGitFiles passes the files of the pinned ref which match the paths to readFile. Paths are
+// relative to the root of the repository and files are visited in tree order.
+func (f FileSetDef) readGitFiles(readFile func(filename string, mode fs.FileMode, content []byte) error) error {
+	switch f.TemplateType {
+	case "", "go/text", "go/html":
+	default:
+		return fmt.Errorf("git file sets do not support template_type %s", f.TemplateType)
+	}
+	tree, commit, err := f.Git.resolveTree()
+	if err != nil {
+		return err
+	}
+	for _, globPath := range f.GlobPaths {
+		count := 0
+		err = tree.Files().ForEach(func(file *object.File) error {
+			if !file.Mode.IsFile() || file.Mode == filemode.Symlink {
+				return nil
+			}
+			matched, err := f.gitFileMatches(globPath, file.Name)
+			if err != nil || !matched {
+				return err
+			}
+			content, err := file.Contents()
+			if err != nil {
+				return err
+			}
+			mode, err := file.Mode.ToOSFileMode()
+			if err != nil {
+				return err
+			}
+			count++
+			return readFile(fmt.Sprintf("%s@%s:%s", f.Git.Repository, commit, file.Name), mode, []byte(content))
+		})
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return fmt.Errorf("no files found for glob %s in %s@%s", globPath, f.Git.Repository, FirstNonNullString(f.Git.Ref, "HEAD"))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package kube
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+func commitTestFile(t *testing.T, repo *git.Repository, dir, name, content string) {
+	path := filepath.Join(dir, name)
+	err := os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(path, []byte(content), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = worktree.Add(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = worktree.Commit("update "+name, &git.CommitOptions{
+		Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReadGitFiles(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	commitTestFile(t, repo, dir, "deploy/cm.yaml", "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: v1\n")
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = repo.CreateTag("v1", head.Hash(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	commitTestFile(t, repo, dir, "deploy/cm.yaml", "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: v2\n")
+
+	testCases := []struct {
+		ref      string
+		expected []string
+	}{
+		{"v1", []string{"v1"}},
+		{"", []string{"v2"}},
+	}
+	for _, tc := range testCases {
+		f := FileSetDef{
+			GlobPaths:     []string{"deploy"},
+			SplitYamlDocs: true,
+			Git:           &GitOptions{Repository: dir, Ref: tc.ref},
+		}
+		var names []string
+		err = f.ExpandContent(ExpandedContentHandlerFunc(func(ec *ExpandedContent) error {
+			u, err := ParseSingleYamlManifest(string(ec.Content))
+			if err != nil {
+				return err
+			}
+			names = append(names, u.GetName())
+			return nil
+		}))
+		if err != nil {
+			t.Errorf("%s: %v", tc.ref, err)
+			continue
+		}
+		if !reflect.DeepEqual(names, tc.expected) {
+			t.Errorf("%s: got %v, expected %v", tc.ref, names, tc.expected)
+		}
+	}
+}
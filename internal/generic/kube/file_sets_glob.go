@@ -0,0 +1,117 @@
+package kube
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+var manifestExtensions = []string{".yaml", ".yml", ".json"}
+
+func isManifestFile(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, manifestExt := range manifestExtensions {
+		if ext == manifestExt {
+			return true
+		}
+	}
+	return false
+}
+
+// isExcluded matches a path against the exclude patterns. Patterns without a path separator are
+// also matched against the base name so that `*.tmpl.yaml` works without a leading `**/`.
+func (f FileSetDef) isExcluded(path string) (bool, error) {
+	for _, pattern := range f.Excludes {
+		matched, err := doublestar.PathMatch(filepath.FromSlash(pattern), path)
+		if err != nil {
+			return false, fmt.Errorf("invalid exclude pattern %s: %w", pattern, err)
+		}
+		if !matched && !strings.ContainsAny(pattern, `/\`) {
+			matched, err = doublestar.PathMatch(pattern, filepath.Base(path))
+			if err != nil {
+				return false, fmt.Errorf("invalid exclude pattern %s: %w", pattern, err)
+			}
+		}
+		if matched {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// globPaths expands a pattern which may contain `**`, removes excluded paths and returns the
+// matches in lexical order. It is an error when nothing is left, as a typo in an exclude pattern
+// is as likely as one in the glob.
+func (f FileSetDef) globPaths(globPath string) ([]string, error) {
+	matches, err := doublestar.FilepathGlob(globPath)
+	if err != nil {
+		return nil, fmt.Errorf("invalid glob %s: %w", globPath, err)
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("no files found for glob %s", globPath)
+	}
+	sort.Strings(matches)
+	result := make([]string, 0, len(matches))
+	for _, match := range matches {
+		excluded, err := f.isExcluded(match)
+		if err != nil {
+			return nil, err
+		}
+		if !excluded {
+			result = append(result, match)
+		}
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("no files found for glob %s, every match is excluded", globPath)
+	}
+	return result, nil
+}
+
+// expandFilePaths is like globPaths but directories are replaced by all the yaml and json files
+// beneath them ( or all files when documents are not being split ).
+func (f FileSetDef) expandFilePaths(globPath string) ([]string, error) {
+	matches, err := f.globPaths(globPath)
+	if err != nil {
+		return nil, err
+	}
+	var result []string
+	for _, match := range matches {
+		stats, err := os.Stat(match)
+		if err != nil {
+			return nil, err
+		}
+		if !stats.IsDir() {
+			result = append(result, match)
+			continue
+		}
+		// WalkDir visits entries in lexical order so the result is deterministic
+		err = filepath.WalkDir(match, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			excluded, err := f.isExcluded(path)
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				if excluded && path != match {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !excluded && (!f.SplitYamlDocs || isManifestFile(path)) {
+				result = append(result, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,139 @@
+package kube
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeTestFiles(t *testing.T, dir string, names ...string) {
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		err := os.MkdirAll(filepath.Dir(path), 0o755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.WriteFile(path, []byte("kind: Test\n"), 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestExpandFilePaths(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir,
+		"apps/team-b/svc/deploy.yaml",
+		"apps/team-a/svc2/service.yml",
+		"apps/team-a/svc1/deploy.yaml",
+		"apps/team-a/svc1/notes.txt",
+		"apps/team-a/svc1/config.json",
+		"apps/team-a/svc1/skip.tmpl.yaml",
+		"apps/team-c/ignored/deploy.yaml",
+	)
+	rel := func(paths []string) []string {
+		var result []string
+		for _, path := range paths {
+			r, _ := filepath.Rel(dir, path)
+			result = append(result, filepath.ToSlash(r))
+		}
+		return result
+	}
+
+	testCases := []struct {
+		glob     string
+		excludes []string
+		expected []string
+		err      bool
+	}{
+		{
+			glob: "apps/**/*.yaml",
+			expected: []string{
+				"apps/team-a/svc1/deploy.yaml",
+				"apps/team-a/svc1/skip.tmpl.yaml",
+				"apps/team-b/svc/deploy.yaml",
+				"apps/team-c/ignored/deploy.yaml",
+			},
+		},
+		{
+			glob:     "apps",
+			excludes: []string{"*.tmpl.yaml", filepath.Join(dir, "**/team-c")},
+			expected: []string{
+				"apps/team-a/svc1/config.json",
+				"apps/team-a/svc1/deploy.yaml",
+				"apps/team-a/svc2/service.yml",
+				"apps/team-b/svc/deploy.yaml",
+			},
+		},
+		{
+			glob: "apps/*",
+			expected: []string{
+				"apps/team-a/svc1/config.json",
+				"apps/team-a/svc1/deploy.yaml",
+				"apps/team-a/svc1/skip.tmpl.yaml",
+				"apps/team-a/svc2/service.yml",
+				"apps/team-b/svc/deploy.yaml",
+				"apps/team-c/ignored/deploy.yaml",
+			},
+		},
+		{
+			glob:     "apps/team-c",
+			excludes: []string{"team-c"},
+			err:      true,
+		},
+		{
+			glob: "apps/team-d",
+			err:  true,
+		},
+	}
+
+	for _, tc := range testCases {
+		f := FileSetDef{Excludes: tc.excludes, SplitYamlDocs: true}
+		result, err := f.expandFilePaths(filepath.Join(dir, tc.glob))
+		if tc.err {
+			if err == nil {
+				t.Errorf("%s: expected an error, got %v", tc.glob, rel(result))
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tc.glob, err)
+			continue
+		}
+		if !reflect.DeepEqual(rel(result), tc.expected) {
+			t.Errorf("%s: expandFilePaths() = %v, expected %v", tc.glob, rel(result), tc.expected)
+		}
+	}
+}
+
+func TestRelativePath(t *testing.T) {
+	dir := t.TempDir()
+	testCases := []struct {
+		fsd      FileSetDef
+		filename string
+		expected string
+		err      bool
+	}{
+		{filename: filepath.Join(dir, "a", "config.yaml"), expected: "a/config.yaml"},
+		{filename: filepath.Join(dir, "b", "config.yaml"), expected: "b/config.yaml"},
+		{filename: filepath.Join(dir, "charts.tar.gz") + archiveSeparator + "x/values.yaml", expected: "charts.tar.gz//x/values.yaml"},
+		{filename: filepath.Join(filepath.Dir(dir), "other.yaml"), err: true},
+		{fsd: FileSetDef{Git: &GitOptions{Repository: "/repo"}}, filename: "/repo@abc123:deploy/app.yaml", expected: "deploy/app.yaml"},
+	}
+	for _, tc := range testCases {
+		actual, err := tc.fsd.RelativePath(dir, tc.filename)
+		if tc.err {
+			if err == nil {
+				t.Errorf("expected an error for %s", tc.filename)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual != tc.expected {
+			t.Errorf("expected %s, got %s", tc.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package kube
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/strvals"
+)
+
+type HelmOptions struct {
+	ReleaseName string
+	Namespace   string
+	Values      string
+	IncludeCRDs bool
+}
+
+func (f FileSetDef) helmValues() (map[string]any, error) {
+	values := make(map[string]any)
+	if f.Helm != nil && f.Helm.Values != "" {
+		parsed, err := chartutil.ReadValues([]byte(f.Helm.Values))
+		if err != nil {
+			return nil, fmt.Errorf("error parsing helm values: %w", err)
+		}
+		values = parsed.AsMap()
+	}
+	// variables behave like --set arguments and override the values document
+	for key, value := range f.Variables {
+		err := setHelmValue(key, value, values)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing helm variable %s: %w", key, err)
+		}
+	}
+	return values, nil
+}
+
+// helmValueEscaper escapes the characters --set gives a meaning to in values, so a value is set
+// as it is rather than split into several values or a list.
+var helmValueEscaper = strings.NewReplacer(`\`, `\\`, ",", `\,`, "{", `\{`)
+
+// setHelmValue sets the value at the path key like --set. Strings are typed like --set, so
+// numbers and booleans become numbers and booleans, other values are set like --set-json.
+func setHelmValue(key string, value any, values map[string]any) error {
+	if s, ok := value.(string); ok {
+		return strvals.ParseInto(key+"="+helmValueEscaper.Replace(s), values)
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return strvals.ParseJSON(key+"="+string(encoded), values)
+}
+
+// renderHelmChart renders a local chart directory or archive offline, as `helm template` does.
+// Hooks are not included as they have no meaning outside of a helm release.
+func (f FileSetDef) renderHelmChart(chartPath string) ([]byte, error) {
+	chart, err := loader.Load(chartPath)
+	if err != nil {
+		return nil, err
+	}
+	if chart.Metadata.Dependencies != nil {
+		err = action.CheckDependencies(chart, chart.Metadata.Dependencies)
+		if err != nil {
+			return nil, err
+		}
+	}
+	values, err := f.helmValues()
+	if err != nil {
+		return nil, err
+	}
+
+	options := HelmOptions{}
+	if f.Helm != nil {
+		options = *f.Helm
+	}
+
+	install := action.NewInstall(&action.Configuration{
+		Log: func(string, ...interface{}) {},
+	})
+	install.DryRun = true
+	install.DryRunOption = "true"
+	install.ClientOnly = true
+	install.Replace = true
+	install.DisableHooks = true
+	install.ReleaseName = FirstNonNullString(options.ReleaseName, chart.Name())
+	install.Namespace = FirstNonNullString(options.Namespace, "default")
+	install.IncludeCRDs = options.IncludeCRDs
+
+	release, err := install.Run(chart, values)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(release.Manifest), nil
+}
+
+func (f FileSetDef) expandHelmCharts(handler ExpandedContentHandler) error {
+	for _, globPath := range f.GlobPaths {
+		realPaths, err := f.globPaths(globPath)
+		if err != nil {
+			return err
+		}
+		for _, realPath := range realPaths {
+			content, err := f.renderHelmChart(realPath)
+			if err != nil {
+				return fmt.Errorf("helm template %s: %w", realPath, err)
+			}
+			ec := ExpandedContent{Filename: realPath, Content: content}
+			if f.SplitYamlDocs {
+				err = f.splitYamlDocuments(ec, handler)
+			} else {
+				err = f.processDocument(&ec, handler)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
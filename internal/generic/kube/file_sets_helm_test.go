@@ -0,0 +1,132 @@
+package kube
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestHelmValues(t *testing.T) {
+	testCases := []struct {
+		name      string
+		values    string
+		variables map[string]any
+		expected  map[string]any
+		err       bool
+	}{
+		{
+			name:     "values document",
+			values:   "replicas: 1\nimage:\n  tag: v1\n",
+			expected: map[string]any{"replicas": float64(1), "image": map[string]any{"tag": "v1"}},
+		},
+		{
+			name:      "variables override values",
+			values:    "replicas: 1\nimage:\n  tag: v1\n  name: app\n",
+			variables: map[string]any{"replicas": "3", "image.tag": "v2"},
+			expected:  map[string]any{"replicas": int64(3), "image": map[string]any{"tag": "v2", "name": "app"}},
+		},
+		{
+			name:      "commas and equals",
+			variables: map[string]any{"args": "a=b,c=d", "list": "{x,y}", "path": `c:\temp`},
+			expected:  map[string]any{"args": "a=b,c=d", "list": "{x,y}", "path": `c:\temp`},
+		},
+		{
+			name:      "booleans",
+			variables: map[string]any{"enabled": "true"},
+			expected:  map[string]any{"enabled": true},
+		},
+		{
+			name:      "nested map",
+			values:    "labels:\n  a: b\n",
+			variables: map[string]any{"labels": map[string]any{"c": "d,e", "n": 2}},
+			expected:  map[string]any{"labels": map[string]any{"c": "d,e", "n": float64(2)}},
+		},
+		{
+			name:   "invalid values",
+			values: "a: [",
+			err:    true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			f := FileSetDef{Helm: &HelmOptions{Values: tc.values}, Variables: tc.variables}
+			values, err := f.helmValues()
+			if (err != nil) != tc.err {
+				t.Fatalf("expected error=%v, got %v", tc.err, err)
+			}
+			if !tc.err && !reflect.DeepEqual(values, tc.expected) {
+				t.Errorf("expected %#v, got %#v", tc.expected, values)
+			}
+		})
+	}
+}
+
+func TestExpandHelmChart(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"Chart.yaml":            "apiVersion: v2\nname: demo\nversion: 0.1.0\n",
+		"values.yaml":           "replicas: 1\nargs: none\n",
+		"templates/config.yaml": "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: {{ .Release.Name }}-config\n  namespace: {{ .Release.Namespace }}\ndata:\n  replicas: {{ .Values.replicas | quote }}\n  args: {{ .Values.args | quote }}\n",
+	}
+	for name, content := range files {
+		err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	testCases := []struct {
+		name      string
+		options   *HelmOptions
+		variables map[string]any
+		expected  map[string]any
+	}{
+		{
+			name:     "defaults",
+			expected: map[string]any{"name": "demo-config", "namespace": "default", "replicas": "1", "args": "none"},
+		},
+		{
+			name:      "options and variables",
+			options:   &HelmOptions{ReleaseName: "web", Namespace: "apps", Values: "replicas: 2\n"},
+			variables: map[string]any{"args": "--a=1,--b=2"},
+			expected:  map[string]any{"name": "web-config", "namespace": "apps", "replicas": "2", "args": "--a=1,--b=2"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			f := FileSetDef{
+				GlobPaths:     []string{dir},
+				TemplateType:  "helm",
+				Helm:          tc.options,
+				Variables:     tc.variables,
+				SplitYamlDocs: true,
+			}
+			var results []map[string]any
+			err := f.ExpandContent(ExpandedContentHandlerFunc(func(ec *ExpandedContent) error {
+				u, err := ParseSingleYamlManifest(string(ec.Content))
+				if err != nil {
+					return err
+				}
+				data := u.Object["data"].(map[string]any)
+				results = append(results, map[string]any{
+					"name":      u.GetName(),
+					"namespace": u.GetNamespace(),
+					"replicas":  data["replicas"],
+					"args":      data["args"],
+				})
+				return nil
+			}))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(results) != 1 || !reflect.DeepEqual(results[0], tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, results)
+			}
+		})
+	}
+}
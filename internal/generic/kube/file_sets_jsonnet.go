@@ -0,0 +1,138 @@
+package kube
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/google/go-jsonnet"
+	"sigs.k8s.io/yaml"
+)
+
+type JsonnetOptions struct {
+	LibraryPaths      []string
+	TopLevelArguments bool
+}
+
+func (f FileSetDef) newJsonnetVM(filename string) *jsonnet.VM {
+	vm := jsonnet.MakeVM()
+	libraryPaths := []string{filepath.Dir(filename)}
+	topLevelArguments := false
+	if f.Jsonnet != nil {
+		libraryPaths = append(libraryPaths, f.Jsonnet.LibraryPaths...)
+		topLevelArguments = f.Jsonnet.TopLevelArguments
+	}
+	vm.Importer(&jsonnet.FileImporter{JPaths: libraryPaths})
+	for key, value := range f.Variables {
+		if topLevelArguments {
+			vm.TLAVar(key, fmt.Sprintf("%v", value))
+		} else {
+			vm.ExtVar(key, fmt.Sprintf("%v", value))
+		}
+	}
+	return vm
+}
+
+// flattenDocuments turns the output of a jsonnet program into a list of kubernetes objects. The
+// output may be a single object, an array of objects, a List kind, or an object whose fields are
+// all objects ( as produced for `jsonnet -m` ).
+func flattenDocuments(v any) ([]map[string]any, error) {
+	switch v := v.(type) {
+	case []any:
+		var result []map[string]any
+		for _, item := range v {
+			items, err := flattenDocuments(item)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, items...)
+		}
+		return result, nil
+	case map[string]any:
+		kind, _ := v["kind"].(string)
+		if kind != "" {
+			return expandListItems(v)
+		}
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		var result []map[string]any
+		for _, key := range keys {
+			items, err := flattenDocuments(v[key])
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", key, err)
+			}
+			result = append(result, items...)
+		}
+		return result, nil
+	case nil:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("expected an object or array, got %T", v)
+	}
+}
+
+// processGeneratedValue passes the objects generated by a jsonnet or cue program to the handler as
+// yaml documents.
+func (f FileSetDef) processGeneratedValue(filename string, value any, handler ExpandedContentHandler) error {
+	if !f.SplitYamlDocs {
+		content, err := yaml.Marshal(value)
+		if err != nil {
+			return err
+		}
+		ec := ExpandedContent{Filename: filename, Content: content}
+		return f.processDocument(&ec, handler)
+	}
+	documents, err := flattenDocuments(value)
+	if err != nil {
+		return fmt.Errorf("output of %s: %w", filename, err)
+	}
+	for _, document := range documents {
+		content, err := yaml.Marshal(document)
+		if err != nil {
+			return err
+		}
+		ec := ExpandedContent{Filename: filename, Content: content}
+		err = f.processDocument(&ec, handler)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f FileSetDef) expandJsonnet(handler ExpandedContentHandler) error {
+	for _, globPath := range f.GlobPaths {
+		realPaths, err := f.globPaths(globPath)
+		if err != nil {
+			return err
+		}
+		for _, realPath := range realPaths {
+			output, err := f.newJsonnetVM(realPath).EvaluateFile(realPath)
+			if err != nil {
+				return err
+			}
+			if !f.SplitYamlDocs {
+				ec := ExpandedContent{Filename: realPath, Content: []byte(output)}
+				err = f.processDocument(&ec, handler)
+				if err != nil {
+					return err
+				}
+				continue
+			}
+			var value any
+			err = json.Unmarshal([]byte(output), &value)
+			if err != nil {
+				return fmt.Errorf("error parsing jsonnet output of %s: %w", realPath, err)
+			}
+			err = f.processGeneratedValue(realPath, value, handler)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package kube
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFlattenDocuments(t *testing.T) {
+	cm := map[string]any{"apiVersion": "v1", "kind": "ConfigMap"}
+	svc := map[string]any{"apiVersion": "v1", "kind": "Service"}
+	testCases := []struct {
+		name     string
+		input    any
+		expected []map[string]any
+		err      bool
+	}{
+		{
+			name:     "object",
+			input:    cm,
+			expected: []map[string]any{cm},
+		},
+		{
+			name:     "array",
+			input:    []any{cm, svc},
+			expected: []map[string]any{cm, svc},
+		},
+		{
+			name:     "list",
+			input:    map[string]any{"apiVersion": "v1", "kind": "List", "items": []any{cm, svc}},
+			expected: []map[string]any{cm, svc},
+		},
+		{
+			name:     "typed list",
+			input:    map[string]any{"apiVersion": "v1", "kind": "ConfigMapList", "items": []any{cm}},
+			expected: []map[string]any{cm},
+		},
+		{
+			name:     "multi file object",
+			input:    map[string]any{"b-service": svc, "a-configmap": cm},
+			expected: []map[string]any{cm, svc},
+		},
+		{
+			name:  "scalar",
+			input: "garbage",
+			err:   true,
+		},
+	}
+
+	for _, tc := range testCases {
+		result, err := flattenDocuments(tc.input)
+		if (err != nil) != tc.err || !reflect.DeepEqual(result, tc.expected) {
+			t.Errorf("%s: flattenDocuments() = %v, %v, expected %v, error=%v", tc.name, result, err, tc.expected, tc.err)
+		}
+	}
+}
+
+func TestExpandJsonnet(t *testing.T) {
+	dir := t.TempDir()
+	lib := `{ configMap(name):: { apiVersion: "v1", kind: "ConfigMap", metadata: { name: name } } }`
+	main := `local lib = import "lib.libsonnet"; [lib.configMap(std.extVar("name")), lib.configMap("other")]`
+	err := os.WriteFile(filepath.Join(dir, "lib.libsonnet"), []byte(lib), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(dir, "main.jsonnet"), []byte(main), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f := FileSetDef{
+		GlobPaths:     []string{filepath.Join(dir, "*.jsonnet")},
+		TemplateType:  "jsonnet",
+		Variables:     map[string]any{"name": "first"},
+		SplitYamlDocs: true,
+	}
+	var names []string
+	err = f.ExpandContent(ExpandedContentHandlerFunc(func(ec *ExpandedContent) error {
+		u, err := ParseSingleYamlManifest(string(ec.Content))
+		if err != nil {
+			return err
+		}
+		names = append(names, u.GetName())
+		return nil
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(names, []string{"first", "other"}) {
+		t.Errorf("unexpected documents %v", names)
+	}
+}
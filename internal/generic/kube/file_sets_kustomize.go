@@ -0,0 +1,69 @@
+package kube
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"sigs.k8s.io/kustomize/api/krusty"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+)
+
+var kustomizationFilenames = []string{"kustomization.yaml", "kustomization.yml", "Kustomization"}
+
+// kustomizationDir returns the directory holding the kustomization for a path that is either the
+// directory itself or the kustomization file within it.
+func kustomizationDir(realPath string) (string, error) {
+	stats, err := os.Stat(realPath)
+	if err != nil {
+		return "", err
+	}
+	if stats.IsDir() {
+		return realPath, nil
+	}
+	base := filepath.Base(realPath)
+	for _, name := range kustomizationFilenames {
+		if base == name {
+			return filepath.Dir(realPath), nil
+		}
+	}
+	return "", fmt.Errorf("%s is not a kustomization directory or file", realPath)
+}
+
+func (f FileSetDef) expandKustomizations(handler ExpandedContentHandler) error {
+	if len(f.Variables) > 0 {
+		return fmt.Errorf("variables are not supported when template_type is kustomize")
+	}
+	fSys := filesys.MakeFsOnDisk()
+	kustomizer := krusty.MakeKustomizer(krusty.MakeDefaultOptions())
+	for _, globPath := range f.GlobPaths {
+		realPaths, err := f.globPaths(globPath)
+		if err != nil {
+			return err
+		}
+		for _, realPath := range realPaths {
+			dir, err := kustomizationDir(realPath)
+			if err != nil {
+				return err
+			}
+			resMap, err := kustomizer.Run(fSys, dir)
+			if err != nil {
+				return fmt.Errorf("kustomize build %s: %w", dir, err)
+			}
+			content, err := resMap.AsYaml()
+			if err != nil {
+				return fmt.Errorf("kustomize build %s: %w", dir, err)
+			}
+			ec := ExpandedContent{Filename: dir, Content: content}
+			if f.SplitYamlDocs {
+				err = f.splitYamlDocuments(ec, handler)
+			} else {
+				err = f.processDocument(&ec, handler)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package kube
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestExpandKustomizations(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"base/kustomization.yaml":    "resources:\n- config.yaml\n",
+		"base/config.yaml":           "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: config\ndata:\n  a: b\n",
+		"overlay/kustomization.yaml": "resources:\n- ../base\nnamespace: apps\nnamePrefix: web-\nconfigMapGenerator:\n- name: extra\n  literals:\n  - c=d\n",
+	}
+	for name, content := range files {
+		err := os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0o755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	testCases := []struct {
+		name      string
+		paths     []string
+		variables map[string]any
+		expected  []string
+		err       bool
+	}{
+		{
+			name:     "directory",
+			paths:    []string{filepath.Join(dir, "base")},
+			expected: []string{"ConfigMap::config"},
+		},
+		{
+			name:     "kustomization file",
+			paths:    []string{filepath.Join(dir, "overlay", "kustomization.yaml")},
+			expected: []string{"ConfigMap:apps:web-config", "ConfigMap:apps:web-extra-gbdtcf54mt"},
+		},
+		{
+			name:  "not a kustomization",
+			paths: []string{filepath.Join(dir, "base", "config.yaml")},
+			err:   true,
+		},
+		{
+			name:      "variables",
+			paths:     []string{filepath.Join(dir, "base")},
+			variables: map[string]any{"a": "b"},
+			err:       true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			f := FileSetDef{
+				GlobPaths:     tc.paths,
+				TemplateType:  "kustomize",
+				Variables:     tc.variables,
+				SplitYamlDocs: true,
+			}
+			var keys []string
+			err := f.ExpandContent(ExpandedContentHandlerFunc(func(ec *ExpandedContent) error {
+				u, err := ParseSingleYamlManifest(string(ec.Content))
+				if err != nil {
+					return err
+				}
+				keys = append(keys, u.GetKind()+":"+u.GetNamespace()+":"+u.GetName())
+				return nil
+			}))
+			if (err != nil) != tc.err {
+				t.Fatalf("expected error=%v, got %v", tc.err, err)
+			}
+			if !tc.err && !reflect.DeepEqual(keys, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, keys)
+			}
+		})
+	}
+}
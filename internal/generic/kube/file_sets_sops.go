@@ -0,0 +1,116 @@
+package kube
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/getsops/sops/v3"
+	"github.com/getsops/sops/v3/aes"
+	"github.com/getsops/sops/v3/age"
+	"github.com/getsops/sops/v3/cmd/sops/common"
+	"github.com/getsops/sops/v3/cmd/sops/formats"
+	"github.com/getsops/sops/v3/config"
+)
+
+type SopsOptions struct {
+	AgeKeys    []string
+	AgeKeyFile string
+}
+
+func (o *SopsOptions) ageIdentities() (age.ParsedIdentities, error) {
+	var identities age.ParsedIdentities
+	if len(o.AgeKeys) > 0 {
+		err := identities.Import(o.AgeKeys...)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if o.AgeKeyFile != "" {
+		path, err := ExpandEnv(o.AgeKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("error reading age key file: %w", err)
+		}
+		err = identities.Import(string(content))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return identities, nil
+}
+
+// dataKey recovers the data key of a sops tree using the configured age identities. When no
+// identities are configured the standard sops key lookup ( SOPS_AGE_KEY, kms, pgp etc ) is used.
+func (o *SopsOptions) dataKey(tree *sops.Tree) ([]byte, error) {
+	identities, err := o.ageIdentities()
+	if err != nil {
+		return nil, err
+	}
+	if len(identities) == 0 || len(tree.Metadata.KeyGroups) != 1 {
+		return tree.Metadata.GetDataKey()
+	}
+	var errs []error
+	for _, key := range tree.Metadata.KeyGroups[0] {
+		ageKey, ok := key.(*age.MasterKey)
+		if !ok {
+			continue
+		}
+		identities.ApplyToMasterKey(ageKey)
+		dataKey, err := ageKey.Decrypt()
+		if err == nil {
+			return dataKey, nil
+		}
+		errs = append(errs, err)
+	}
+	if len(errs) == 0 {
+		return nil, fmt.Errorf("no age recipients found in sops metadata")
+	}
+	return nil, errors.Join(errs...)
+}
+
+// decrypt returns the plaintext of a sops encrypted yaml or json file. Files without sops
+// metadata are returned unchanged with encrypted set to false.
+func (o *SopsOptions) decrypt(filename string, content []byte) (plaintext []byte, encrypted bool, err error) {
+	format := formats.FormatForPath(filename)
+	if format != formats.Yaml && format != formats.Json {
+		return content, false, nil
+	}
+	store := common.StoreForFormat(format, config.NewStoresConfig())
+	tree, err := store.LoadEncryptedFile(content)
+	if err != nil {
+		if errors.Is(err, sops.MetadataNotFound) {
+			return content, false, nil
+		}
+		return nil, false, err
+	}
+	key, err := o.dataKey(&tree)
+	if err != nil {
+		return nil, true, fmt.Errorf("error getting sops data key for %s: %w", filename, err)
+	}
+	cipher := aes.NewCipher()
+	mac, err := tree.Decrypt(key, cipher)
+	if err != nil {
+		return nil, true, fmt.Errorf("error decrypting %s: %w", filename, err)
+	}
+	originalMac, err := cipher.Decrypt(
+		tree.Metadata.MessageAuthenticationCode,
+		key,
+		tree.Metadata.LastModified.Format(time.RFC3339),
+	)
+	if err != nil {
+		return nil, true, fmt.Errorf("error decrypting mac of %s: %w", filename, err)
+	}
+	if originalMac != mac {
+		return nil, true, fmt.Errorf("failed to verify data integrity of %s", filename)
+	}
+	plaintext, err = store.EmitPlainFile(tree.Branches)
+	if err != nil {
+		return nil, true, err
+	}
+	return plaintext, true, nil
+}
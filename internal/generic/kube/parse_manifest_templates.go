@@ -1,11 +1,10 @@
 package kube
 
 import (
-	"bufio"
+	"bytes"
 	"fmt"
 	"os"
 	"path/filepath"
-	"strings"
 	"text/template"
 
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -34,27 +33,9 @@ type unparsedDocument struct {
 	}
 }
 
-func (doc *unparsedDocument) ExpandTemplateAndParse(variables map[string]any) (*ParsedDocument, error) {
-	// Implement the parsing logic here
-	// This is a placeholder implementation
-
+func (doc *unparsedDocument) Parse() (*ParsedDocument, error) {
 	s := doc.Manifest
 
-	if len(variables) > 0 {
-		//create a template from the manifest and expand it with the variables
-		source := fmt.Sprintf("%s [line:%d]", doc.Source.Filename, doc.Source.Line)
-		t, err := template.New(source).Parse(s)
-		if err != nil {
-			return nil, err
-		}
-		var buf strings.Builder
-		err = t.Execute(&buf, variables)
-		if err != nil {
-			return nil, err
-		}
-		s = buf.String()
-	}
-
 	unstructuredObj, err := ParseSingleYamlManifest(s)
 	if err != nil {
 		return nil, err
@@ -92,59 +73,34 @@ func (doc *unparsedDocument) ExpandTemplateAndParse(variables map[string]any) (*
 	return parsedDoc, nil
 }
 
-func readDocumentsFromFileAndSplit(filePath string) ([]unparsedDocument, error) {
-	// Implement the file reading and splitting logic here
-	// This is a placeholder implementation
-	var documents []unparsedDocument
-	f, err := os.Open(filePath)
+func readDocumentsFromFileAndSplit(filePath string, variables map[string]any) ([]unparsedDocument, error) {
+	content, err := os.ReadFile(filePath)
 	if err != nil {
 		return nil, err
 	}
-	defer f.Close()
-	// Read the yaml file and split it into documents with line numbers. Split on lines that are "---"
-	scanner := bufio.NewScanner(f)
-	currentManifest := strings.Builder{}
-	lineNumber := 0
-	for scanner.Scan() {
-		line := scanner.Text()
-		lineNumber++
-		if line == "---" {
-			s := strings.TrimSpace(currentManifest.String())
-			if len(s) > 0 {
-				documents = append(documents, unparsedDocument{
-					Manifest: s,
-					Source: struct {
-						Filename string
-						Line     int
-					}{
-						Filename: filePath,
-						Line:     lineNumber,
-					},
-				})
-				currentManifest.Reset()
-			}
-			continue
-		} else {
-			// Add the line to the current manifest
-			currentManifest.WriteString(line + "\n")
+	if len(variables) > 0 {
+		//expand the whole file so templates may generate or span several documents
+		t, err := template.New(filePath).Parse(string(content))
+		if err != nil {
+			return nil, err
 		}
+		var buf bytes.Buffer
+		err = t.Execute(&buf, variables)
+		if err != nil {
+			return nil, err
+		}
+		content = buf.Bytes()
 	}
-	if err := scanner.Err(); err != nil {
+	yamlDocuments, err := DecodeYamlStream(filePath, content)
+	if err != nil {
 		return nil, err
 	}
-	s := strings.TrimSpace(currentManifest.String())
-	if len(s) > 0 {
-		documents = append(documents, unparsedDocument{
-			Manifest: s,
-			Source: struct {
-				Filename string
-				Line     int
-			}{
-				Filename: filePath,
-				Line:     lineNumber,
-			},
-		})
-		currentManifest.Reset()
+	var documents []unparsedDocument
+	for _, yamlDocument := range yamlDocuments {
+		doc := unparsedDocument{Manifest: string(yamlDocument.Content)}
+		doc.Source.Filename = filePath
+		doc.Source.Line = yamlDocument.LineNo
+		documents = append(documents, doc)
 	}
 	if len(documents) == 0 {
 		return nil, fmt.Errorf("no documents found in file: %s", filePath)
@@ -168,12 +124,12 @@ func SplitAndExpandTemplates(templateFiles []string, variables map[string]any) (
 	}
 
 	for _, filePath := range globbedFiles {
-		unparsedDocuments, err := readDocumentsFromFileAndSplit(filePath)
+		unparsedDocuments, err := readDocumentsFromFileAndSplit(filePath, variables)
 		if err != nil {
 			return nil, err
 		}
 		for _, unparsedDoc := range unparsedDocuments {
-			parsedDoc, err := unparsedDoc.ExpandTemplateAndParse(variables)
+			parsedDoc, err := unparsedDoc.Parse()
 			if err != nil {
 				return nil, err
 			}
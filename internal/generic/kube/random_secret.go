@@ -0,0 +1,153 @@
+package kube
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const DefaultRandomCharset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+const DefaultRandomLength = 32
+
+type RandomSecretOptions struct {
+	Keys    []string
+	Length  int
+	Charset string
+}
+
+func RandomString(length int, charset string) (string, error) {
+	runes := []rune(charset)
+	if len(runes) == 0 {
+		return "", fmt.Errorf("charset is empty")
+	}
+	if length <= 0 {
+		return "", fmt.Errorf("length must be positive")
+	}
+	result := make([]rune, length)
+	size := big.NewInt(int64(len(runes)))
+	for i := range result {
+		n, err := rand.Int(rand.Reader, size)
+		if err != nil {
+			return "", err
+		}
+		result[i] = runes[n.Int64()]
+	}
+	return string(result), nil
+}
+
+// SecretData returns the decoded data of a Secret.
+func SecretData(u unstructured.Unstructured) (map[string][]byte, error) {
+	encoded, _, err := unstructured.NestedStringMap(u.Object, "data")
+	if err != nil {
+		return nil, err
+	}
+	data := make(map[string][]byte)
+	for key, value := range encoded {
+		data[key], err = base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return nil, fmt.Errorf("value for key %q is not base64: %w", key, err)
+		}
+	}
+	return data, nil
+}
+
+// FillRandomValues returns the values of the keys, generating a value for each key which does
+// not exist. Existing values are only replaced when regenerate is set.
+func (o *RandomSecretOptions) FillRandomValues(existing map[string][]byte, regenerate bool) (map[string][]byte, bool, error) {
+	data := make(map[string][]byte)
+	changed := false
+	for _, key := range o.Keys {
+		value, found := existing[key]
+		if !found || regenerate {
+			s, err := RandomString(o.Length, o.Charset)
+			if err != nil {
+				return nil, false, err
+			}
+			value = []byte(s)
+			changed = true
+		}
+		data[key] = value
+	}
+	return data, changed, nil
+}
+
+// ApplyData returns the data to apply to the current Secret. It has the values of the keys, as
+// FillRandomValues gives them, and the existing values of any other keys which fieldManager
+// applied before, since the api server deletes fields which an apply leaves out.
+func (o *RandomSecretOptions) ApplyData(current unstructured.Unstructured, fieldManager string, regenerate bool) (map[string][]byte, error) {
+	existing, err := SecretData(current)
+	if err != nil {
+		return nil, err
+	}
+	data, _, err := o.FillRandomValues(existing, regenerate)
+	if err != nil {
+		return nil, err
+	}
+	applied, err := appliedDataKeys(current, fieldManager)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range applied {
+		value, found := existing[key]
+		if _, managed := data[key]; found && !managed {
+			data[key] = value
+		}
+	}
+	return data, nil
+}
+
+// appliedDataKeys returns the keys of data which fieldManager owns through server side apply.
+func appliedDataKeys(u unstructured.Unstructured, fieldManager string) ([]string, error) {
+	var keys []string
+	for _, entry := range u.GetManagedFields() {
+		if entry.Manager != fieldManager || entry.Operation != metav1.ManagedFieldsOperationApply || entry.FieldsV1 == nil {
+			continue
+		}
+		var fields map[string]any
+		err := json.Unmarshal(entry.FieldsV1.Raw, &fields)
+		if err != nil {
+			return nil, fmt.Errorf("invalid managed fields of %s: %w", fieldManager, err)
+		}
+		dataFields, _ := fields["f:data"].(map[string]any)
+		for field := range dataFields {
+			if key, ok := strings.CutPrefix(field, "f:"); ok {
+				keys = append(keys, key)
+			}
+		}
+	}
+	return keys, nil
+}
+
+// NewHashSalt returns a random salt for HashSecretValues.
+func NewHashSalt() (string, error) {
+	salt := make([]byte, 16)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(salt), nil
+}
+
+// HashSecretValues returns a hmac sha256 of the values of the keys, so changes can be detected
+// without storing the values. The salt stops the hash being used to guess short values.
+func HashSecretValues(salt string, data map[string][]byte, keys []string) string {
+	sorted := append([]string{}, keys...)
+	sort.Strings(sorted)
+	h := hmac.New(sha256.New, []byte(salt))
+	for _, key := range sorted {
+		value, found := data[key]
+		fmt.Fprintf(h, "%s\x00%t\x00%d\x00", key, found, len(value))
+		h.Write(value)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,119 @@
+package kube
+
+import (
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestRandomString(t *testing.T) {
+	s, err := RandomString(64, "ab")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s) != 64 || strings.Trim(s, "ab") != "" {
+		t.Errorf("unexpected random string %s", s)
+	}
+	_, err = RandomString(8, "")
+	if err == nil {
+		t.Errorf("expected an error for an empty charset")
+	}
+	_, err = RandomString(0, "ab")
+	if err == nil {
+		t.Errorf("expected an error for a zero length")
+	}
+}
+
+func TestFillRandomValues(t *testing.T) {
+	options := RandomSecretOptions{Keys: []string{"password", "admin-password"}, Length: 16, Charset: DefaultRandomCharset}
+	existing := map[string][]byte{"password": []byte("keep"), "other": []byte("ignored")}
+
+	data, changed, err := options.FillRandomValues(existing, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !changed || string(data["password"]) != "keep" || len(data["admin-password"]) != 16 || data["other"] != nil {
+		t.Errorf("unexpected values %q changed=%v", data, changed)
+	}
+
+	again, changed, err := options.FillRandomValues(data, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed || HashSecretValues("salt", again, options.Keys) != HashSecretValues("salt", data, options.Keys) {
+		t.Errorf("expected existing values to be kept")
+	}
+	if HashSecretValues("salt", data, options.Keys) == HashSecretValues("other", data, options.Keys) {
+		t.Errorf("expected the salt to change the hash")
+	}
+
+	rotated, changed, err := options.FillRandomValues(data, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !changed || string(rotated["password"]) == "keep" {
+		t.Errorf("expected values to be regenerated")
+	}
+}
+
+func TestSecretData(t *testing.T) {
+	u := unstructured.Unstructured{Object: map[string]any{"data": map[string]any{"password": "c2VjcmV0"}}}
+	data, err := SecretData(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data["password"]) != "secret" {
+		t.Errorf("unexpected data %q", data)
+	}
+}
+
+func TestApplyDataKeepsRemovedKeys(t *testing.T) {
+	current := unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"metadata":   map[string]any{"name": "passwords"},
+		"data": map[string]any{
+			"password":     "a2VlcA==",
+			"old-password": "b2xk",
+			"token":        "b3RoZXI=",
+		},
+	}}
+	current.SetManagedFields([]metav1.ManagedFieldsEntry{
+		{
+			Manager:   "terraform",
+			Operation: metav1.ManagedFieldsOperationApply,
+			FieldsV1:  &metav1.FieldsV1{Raw: []byte(`{"f:data":{".":{},"f:password":{},"f:old-password":{}},"f:type":{}}`)},
+		},
+		{
+			Manager:   "controller",
+			Operation: metav1.ManagedFieldsOperationApply,
+			FieldsV1:  &metav1.FieldsV1{Raw: []byte(`{"f:data":{"f:token":{}}}`)},
+		},
+	})
+	// old-password has been removed from the keys
+	options := RandomSecretOptions{Keys: []string{"password", "api-key"}, Length: 16, Charset: DefaultRandomCharset}
+
+	data, err := options.ApplyData(current, "terraform", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data["password"]) != "keep" || len(data["api-key"]) != 16 {
+		t.Errorf("unexpected values of the keys %q", data)
+	}
+	if string(data["old-password"]) != "old" {
+		t.Errorf("expected the removed key to keep its value, got %q", data)
+	}
+	if _, found := data["token"]; found || len(data) != 3 {
+		t.Errorf("expected only keys applied by the field manager, got %q", data)
+	}
+
+	rotated, err := options.ApplyData(current, "terraform", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(rotated["password"]) == "keep" || string(rotated["old-password"]) != "old" {
+		t.Errorf("expected only the keys to be regenerated, got %q", rotated)
+	}
+}
@@ -0,0 +1,274 @@
+package kube
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// ObjectRef identifies an object. String uses the same form as the default document key.
+type ObjectRef struct {
+	Group     string `json:"group"`
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+}
+
+func (r ObjectRef) String() string {
+	gk := schema.GroupKind{Group: r.Group, Kind: r.Kind}
+	return fmt.Sprintf("%s:%s:%s", gk.String(), r.Namespace, r.Name)
+}
+
+func RefTo(u unstructured.Unstructured) ObjectRef {
+	gvk := u.GroupVersionKind()
+	return ObjectRef{Group: gvk.Group, Kind: gvk.Kind, Namespace: u.GetNamespace(), Name: u.GetName()}
+}
+
+type Reference struct {
+	From     ObjectRef `json:"from"`
+	To       ObjectRef `json:"to"`
+	Type     string    `json:"type"`
+	Optional bool      `json:"optional"`
+	Found    bool      `json:"found"`
+}
+
+func (r Reference) String() string {
+	return fmt.Sprintf("%s -> %s (%s)", r.From, r.To, r.Type)
+}
+
+type ReferenceGraph struct {
+	Nodes      []ObjectRef `json:"nodes"`
+	References []Reference `json:"references"`
+}
+
+// isBuiltinObject returns true for objects which exist in every cluster, so references to them
+// are never dangling.
+func isBuiltinObject(ref ObjectRef) bool {
+	switch ref.Kind {
+	case "ServiceAccount":
+		return ref.Name == "default"
+	case "ClusterRole":
+		return strings.HasPrefix(ref.Name, "system:") || ref.Name == "cluster-admin" || ref.Name == "admin" || ref.Name == "edit" || ref.Name == "view"
+	}
+	return false
+}
+
+type referenceCollector struct {
+	from       ObjectRef
+	references []Reference
+}
+
+func (c *referenceCollector) addRef(to ObjectRef, refType string, optional bool) {
+	if to.Name == "" {
+		return
+	}
+	c.references = append(c.references, Reference{From: c.from, To: to, Type: refType, Optional: optional})
+}
+
+// add references an object in the same namespace, or a cluster scoped ClusterRole.
+func (c *referenceCollector) add(kind, group, name, refType string, optional bool) {
+	namespace := c.from.Namespace
+	if kind == "ClusterRole" {
+		namespace = ""
+	}
+	c.addRef(ObjectRef{Group: group, Kind: kind, Namespace: namespace, Name: name}, refType, optional)
+}
+
+func nestedString(obj map[string]any, fields ...string) string {
+	value, _, _ := unstructured.NestedString(obj, fields...)
+	return value
+}
+
+func nestedBool(obj map[string]any, fields ...string) bool {
+	value, _, _ := unstructured.NestedBool(obj, fields...)
+	return value
+}
+
+func nestedMaps(obj map[string]any, fields ...string) []map[string]any {
+	values, _, _ := unstructured.NestedSlice(obj, fields...)
+	var result []map[string]any
+	for _, v := range values {
+		if m, ok := v.(map[string]any); ok {
+			result = append(result, m)
+		}
+	}
+	return result
+}
+
+func (c *referenceCollector) collectPodSpec(podSpec map[string]any) {
+	serviceAccount := nestedString(podSpec, "serviceAccountName")
+	c.add("ServiceAccount", "", serviceAccount, "service-account", false)
+	for _, secret := range nestedMaps(podSpec, "imagePullSecrets") {
+		c.add("Secret", "", nestedString(secret, "name"), "image-pull-secret", false)
+	}
+	for _, volume := range nestedMaps(podSpec, "volumes") {
+		c.add("ConfigMap", "", nestedString(volume, "configMap", "name"), "volume", nestedBool(volume, "configMap", "optional"))
+		c.add("Secret", "", nestedString(volume, "secret", "secretName"), "volume", nestedBool(volume, "secret", "optional"))
+		c.add("PersistentVolumeClaim", "", nestedString(volume, "persistentVolumeClaim", "claimName"), "volume", false)
+		for _, source := range nestedMaps(volume, "projected", "sources") {
+			c.add("ConfigMap", "", nestedString(source, "configMap", "name"), "volume", nestedBool(source, "configMap", "optional"))
+			c.add("Secret", "", nestedString(source, "secret", "name"), "volume", nestedBool(source, "secret", "optional"))
+		}
+	}
+	for _, field := range []string{"initContainers", "containers", "ephemeralContainers"} {
+		for _, container := range nestedMaps(podSpec, field) {
+			for _, envFrom := range nestedMaps(container, "envFrom") {
+				c.add("ConfigMap", "", nestedString(envFrom, "configMapRef", "name"), "env", nestedBool(envFrom, "configMapRef", "optional"))
+				c.add("Secret", "", nestedString(envFrom, "secretRef", "name"), "env", nestedBool(envFrom, "secretRef", "optional"))
+			}
+			for _, env := range nestedMaps(container, "env") {
+				c.add("ConfigMap", "", nestedString(env, "valueFrom", "configMapKeyRef", "name"), "env", nestedBool(env, "valueFrom", "configMapKeyRef", "optional"))
+				c.add("Secret", "", nestedString(env, "valueFrom", "secretKeyRef", "name"), "env", nestedBool(env, "valueFrom", "secretKeyRef", "optional"))
+			}
+		}
+	}
+}
+
+func (c *referenceCollector) collect(u unstructured.Unstructured) {
+	gk := u.GroupVersionKind().GroupKind()
+	switch {
+	case gk == schema.GroupKind{Kind: "Pod"}:
+		podSpec, _, _ := unstructured.NestedMap(u.Object, "spec")
+		c.collectPodSpec(podSpec)
+	case podTemplatePaths[gk] != nil:
+		podSpec, _, _ := unstructured.NestedMap(u.Object, slices.Concat(podTemplatePaths[gk], []string{"spec"})...)
+		c.collectPodSpec(podSpec)
+	case gk == schema.GroupKind{Group: "networking.k8s.io", Kind: "Ingress"}:
+		c.add("Service", "", nestedString(u.Object, "spec", "defaultBackend", "service", "name"), "ingress-backend", false)
+		for _, rule := range nestedMaps(u.Object, "spec", "rules") {
+			for _, path := range nestedMaps(rule, "http", "paths") {
+				c.add("Service", "", nestedString(path, "backend", "service", "name"), "ingress-backend", false)
+			}
+		}
+		for _, tls := range nestedMaps(u.Object, "spec", "tls") {
+			c.add("Secret", "", nestedString(tls, "secretName"), "ingress-tls", false)
+		}
+	case gk.Group == "rbac.authorization.k8s.io" && (gk.Kind == "RoleBinding" || gk.Kind == "ClusterRoleBinding"):
+		c.add(nestedString(u.Object, "roleRef", "kind"), "rbac.authorization.k8s.io", nestedString(u.Object, "roleRef", "name"), "role-ref", false)
+		for _, subject := range nestedMaps(u.Object, "subjects") {
+			if nestedString(subject, "kind") != "ServiceAccount" {
+				continue
+			}
+			c.addRef(ObjectRef{
+				Kind:      "ServiceAccount",
+				Namespace: FirstNonNullString(nestedString(subject, "namespace"), c.from.Namespace),
+				Name:      nestedString(subject, "name"),
+			}, "subject", false)
+		}
+	}
+}
+
+func podLabels(u unstructured.Unstructured) (map[string]string, bool) {
+	gk := u.GroupVersionKind().GroupKind()
+	if gk == (schema.GroupKind{Kind: "Pod"}) {
+		return u.GetLabels(), true
+	}
+	path, found := podTemplatePaths[gk]
+	if !found {
+		return nil, false
+	}
+	podLabels, _, _ := unstructured.NestedStringMap(u.Object, slices.Concat(path, []string{"metadata", "labels"})...)
+	return podLabels, true
+}
+
+// selectorReferences links each service to the workloads whose pods it selects.
+func selectorReferences(objects []unstructured.Unstructured) []Reference {
+	var references []Reference
+	for _, service := range objects {
+		if service.GroupVersionKind().GroupKind() != (schema.GroupKind{Kind: "Service"}) {
+			continue
+		}
+		selector, _, _ := unstructured.NestedStringMap(service.Object, "spec", "selector")
+		if len(selector) == 0 {
+			continue
+		}
+		matched := false
+		for _, workload := range objects {
+			podLabels, isWorkload := podLabels(workload)
+			if !isWorkload || workload.GetNamespace() != service.GetNamespace() {
+				continue
+			}
+			if labels.SelectorFromSet(selector).Matches(labels.Set(podLabels)) {
+				matched = true
+				references = append(references, Reference{From: RefTo(service), To: RefTo(workload), Type: "service-selector", Found: true})
+			}
+		}
+		if !matched {
+			references = append(references, Reference{
+				From: RefTo(service),
+				To:   ObjectRef{Kind: "Pod", Namespace: service.GetNamespace(), Name: labels.SelectorFromSet(selector).String()},
+				Type: "service-selector",
+			})
+		}
+	}
+	return references
+}
+
+// AnalyseReferences finds the references between objects. A reference is found when its target
+// is one of the objects or exists in every cluster.
+func AnalyseReferences(objects []unstructured.Unstructured) *ReferenceGraph {
+	graph := &ReferenceGraph{}
+	known := make(map[ObjectRef]bool)
+	for _, u := range objects {
+		ref := RefTo(u)
+		known[ref] = true
+		graph.Nodes = append(graph.Nodes, ref)
+	}
+	for _, u := range objects {
+		c := &referenceCollector{from: RefTo(u)}
+		c.collect(u)
+		for _, reference := range c.references {
+			reference.Found = known[reference.To] || isBuiltinObject(reference.To)
+			graph.References = append(graph.References, reference)
+		}
+	}
+	graph.References = append(graph.References, selectorReferences(objects)...)
+	sort.SliceStable(graph.References, func(i, j int) bool {
+		return graph.References[i].From.String() < graph.References[j].From.String()
+	})
+	return graph
+}
+
+// Dangling returns the references to objects which are missing and not optional.
+func (g *ReferenceGraph) Dangling() []Reference {
+	var result []Reference
+	for _, reference := range g.References {
+		if !reference.Found && !reference.Optional {
+			result = append(result, reference)
+		}
+	}
+	return result
+}
+
+func (g *ReferenceGraph) JSON() (string, error) {
+	data, err := json.MarshalIndent(g, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// DOT returns the graph in graphviz format. Missing targets are drawn dashed.
+func (g *ReferenceGraph) DOT() string {
+	sb := &strings.Builder{}
+	sb.WriteString("digraph references {\n")
+	for _, node := range g.Nodes {
+		fmt.Fprintf(sb, "  %q;\n", node.String())
+	}
+	missing := make(map[ObjectRef]bool)
+	for _, reference := range g.References {
+		if !reference.Found && !missing[reference.To] {
+			missing[reference.To] = true
+			fmt.Fprintf(sb, "  %q [style=dashed];\n", reference.To.String())
+		}
+		fmt.Fprintf(sb, "  %q -> %q [label=%q];\n", reference.From.String(), reference.To.String(), reference.Type)
+	}
+	sb.WriteString("}\n")
+	return sb.String()
+}
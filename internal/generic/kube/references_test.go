@@ -0,0 +1,74 @@
+package kube
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestAnalyseReferences(t *testing.T) {
+	var objects []unstructured.Unstructured
+	for _, document := range []string{
+		`{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "config", "namespace": "app"}}`,
+		`{"apiVersion": "v1", "kind": "Service", "metadata": {"name": "web", "namespace": "app"}, "spec": {"selector": {"app": "web"}}}`,
+		`{"apiVersion": "v1", "kind": "Service", "metadata": {"name": "orphan", "namespace": "app"}, "spec": {"selector": {"app": "none"}}}`,
+		`{"apiVersion": "apps/v1", "kind": "Deployment", "metadata": {"name": "web", "namespace": "app"}, "spec": {"template": {
+			"metadata": {"labels": {"app": "web"}},
+			"spec": {
+				"serviceAccountName": "default",
+				"volumes": [{"name": "a", "configMap": {"name": "config"}}, {"name": "b", "configMap": {"name": "missing"}}],
+				"containers": [{"name": "web", "envFrom": [{"secretRef": {"name": "optional", "optional": true}}]}]
+			}
+		}}}`,
+		`{"apiVersion": "networking.k8s.io/v1", "kind": "Ingress", "metadata": {"name": "web", "namespace": "app"}, "spec": {"rules": [{"http": {"paths": [{"backend": {"service": {"name": "web"}}}]}}]}}`,
+		`{"apiVersion": "rbac.authorization.k8s.io/v1", "kind": "RoleBinding", "metadata": {"name": "read", "namespace": "app"}, "roleRef": {"kind": "ClusterRole", "name": "view"}, "subjects": [{"kind": "ServiceAccount", "name": "robot", "namespace": "ops"}]}`,
+	} {
+		u, err := ParseSingleYamlManifest(document)
+		if err != nil {
+			t.Fatal(err)
+		}
+		objects = append(objects, u)
+	}
+	graph := AnalyseReferences(objects)
+
+	var found []string
+	for _, reference := range graph.References {
+		if reference.Found {
+			found = append(found, reference.String())
+		}
+	}
+	expectedFound := []string{
+		"Deployment.apps:app:web -> ServiceAccount:app:default (service-account)",
+		"Deployment.apps:app:web -> ConfigMap:app:config (volume)",
+		"Ingress.networking.k8s.io:app:web -> Service:app:web (ingress-backend)",
+		"RoleBinding.rbac.authorization.k8s.io:app:read -> ClusterRole.rbac.authorization.k8s.io::view (role-ref)",
+		"Service:app:web -> Deployment.apps:app:web (service-selector)",
+	}
+	if !reflect.DeepEqual(found, expectedFound) {
+		t.Errorf("expected found %v, got %v", expectedFound, found)
+	}
+
+	var dangling []string
+	for _, reference := range graph.Dangling() {
+		dangling = append(dangling, reference.String())
+	}
+	expectedDangling := []string{
+		"Deployment.apps:app:web -> ConfigMap:app:missing (volume)",
+		"RoleBinding.rbac.authorization.k8s.io:app:read -> ServiceAccount:ops:robot (subject)",
+		"Service:app:orphan -> Pod:app:app=none (service-selector)",
+	}
+	if !reflect.DeepEqual(dangling, expectedDangling) {
+		t.Errorf("expected dangling %v, got %v", expectedDangling, dangling)
+	}
+
+	dot := graph.DOT()
+	if !strings.Contains(dot, `"ConfigMap:app:missing" [style=dashed];`) {
+		t.Errorf("expected missing node to be dashed in %s", dot)
+	}
+	_, err := graph.JSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -132,6 +132,14 @@ func (base *ResourceHelper) Fetch(ctx context.Context, state StateInteraface, fe
 	return output, err
 }
 
+// FieldManager is the name changes are applied with.
+func (base *ResourceHelper) FieldManager() string {
+	if base.options == nil || base.options.FieldManager == nil {
+		return ""
+	}
+	return *base.options.FieldManager
+}
+
 func NewResourceHelper(ctx context.Context, sharedApi *APIClientWrapper, apiOptions *APIClientOptions, key ResourceKey) (*ResourceHelper, error) {
 	retryHelper, err := apiOptions.Retry.NewHelper()
 	if err != nil {
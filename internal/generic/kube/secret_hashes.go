@@ -0,0 +1,40 @@
+package kube
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func IsSecret(u unstructured.Unstructured) bool {
+	return u.GetAPIVersion() == "v1" && u.GetKind() == "Secret"
+}
+
+// SecretDataHashes returns the hmac sha256 keyed with salt of each value of a Secret, or nil for
+// other objects. stringData takes precedence over data as it does in the API server, so the
+// hashes of a manifest match the hashes of the live object.
+func SecretDataHashes(salt string, u unstructured.Unstructured) (map[string]string, error) {
+	if !IsSecret(u) {
+		return nil, nil
+	}
+	data, err := SecretData(u)
+	if err != nil {
+		return nil, err
+	}
+	stringData, _, err := unstructured.NestedStringMap(u.Object, "stringData")
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range stringData {
+		data[key] = []byte(value)
+	}
+	hashes := make(map[string]string)
+	for key, value := range data {
+		h := hmac.New(sha256.New, []byte(salt))
+		h.Write(value)
+		hashes[key] = "hmac-sha256:" + hex.EncodeToString(h.Sum(nil))
+	}
+	return hashes, nil
+}
@@ -0,0 +1,46 @@
+package kube
+
+import (
+	"testing"
+)
+
+func TestSecretDataHashes(t *testing.T) {
+	manifest, err := ParseSingleYamlManifest("apiVersion: v1\nkind: Secret\nmetadata:\n  name: s\ndata:\n  a: c2VjcmV0\n  b: b2xk\nstringData:\n  b: new\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	live, err := ParseSingleYamlManifest("apiVersion: v1\nkind: Secret\nmetadata:\n  name: s\ndata:\n  a: c2VjcmV0\n  b: bmV3\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	planned, err := SecretDataHashes("salt", manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	actual, err := SecretDataHashes("salt", live)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(planned) != 2 || planned["a"] != actual["a"] || planned["b"] != actual["b"] {
+		t.Errorf("expected stringData to be hashed like the live data, got %v and %v", planned, actual)
+	}
+	if planned["a"] != "hmac-sha256:98e5340f0f4f96d2b80c2a90da0d03cf46c35e9492918cc7af73d9a39efa5981" {
+		t.Errorf("unexpected hash %s", planned["a"])
+	}
+	salted, err := SecretDataHashes("other", live)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if salted["a"] == actual["a"] {
+		t.Errorf("expected the salt to change the hash")
+	}
+
+	configMap, err := ParseSingleYamlManifest("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: c\ndata:\n  a: b\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	hashes, err := SecretDataHashes("salt", configMap)
+	if err != nil || hashes != nil {
+		t.Errorf("expected no hashes for a config map, got %v %v", hashes, err)
+	}
+}
@@ -38,6 +38,7 @@ type APIClientWrapper struct {
 	configPaths           []string
 	namespace             string
 	resourceTypes         map[string]*ResourceType
+	fieldTypes            *OpenAPIFieldTypes
 	lock                  sync.Mutex
 }
 
@@ -105,11 +106,8 @@ type ResourceType struct {
 	Namespaced bool
 }
 
-func (shared *APIClientWrapper) ResourceInterface(ctx context.Context, apiVersion, kind, namespace string) (dynamic.ResourceInterface, error) {
-
-	shared.lock.Lock()
-	defer shared.lock.Unlock()
-
+// restMapping must be called with the lock held.
+func (shared *APIClientWrapper) restMapping(apiVersion, kind string) (*meta.RESTMapping, error) {
 	gv, err := runtimeschema.ParseGroupVersion(apiVersion)
 	if err != nil {
 		return nil, err
@@ -134,6 +132,18 @@ func (shared *APIClientWrapper) ResourceInterface(ctx context.Context, apiVersio
 		shared.discovery.Invalidate()
 		return nil, err
 	}
+	return mapping, nil
+}
+
+func (shared *APIClientWrapper) ResourceInterface(ctx context.Context, apiVersion, kind, namespace string) (dynamic.ResourceInterface, error) {
+
+	shared.lock.Lock()
+	defer shared.lock.Unlock()
+
+	mapping, err := shared.restMapping(apiVersion, kind)
+	if err != nil {
+		return nil, err
+	}
 
 	var dr dynamic.ResourceInterface
 	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
@@ -146,6 +156,36 @@ func (shared *APIClientWrapper) ResourceInterface(ctx context.Context, apiVersio
 	return dr, nil
 }
 
+// IsNamespaced uses discovery to tell whether a kind is namespaced.
+func (shared *APIClientWrapper) IsNamespaced(apiVersion, kind string) (bool, error) {
+	shared.lock.Lock()
+	defer shared.lock.Unlock()
+
+	mapping, err := shared.restMapping(apiVersion, kind)
+	if err != nil {
+		return false, err
+	}
+	return mapping.Scope.Name() == meta.RESTScopeNameNamespace, nil
+}
+
+// FieldTypes returns a resolver which uses the OpenAPI v3 schema of the cluster and falls back to
+// the built in table when the schema is not available.
+func (shared *APIClientWrapper) FieldTypes(ctx context.Context) FieldTypeResolver {
+	shared.lock.Lock()
+	defer shared.lock.Unlock()
+
+	if shared.fieldTypes == nil {
+		if shared.discovery == nil || shared.dynamic == nil {
+			err := shared.reloadConfig(ctx)
+			if err != nil {
+				return BuiltinFieldTypes
+			}
+		}
+		shared.fieldTypes = NewOpenAPIFieldTypes(shared.discovery.OpenAPIV3())
+	}
+	return ChainFieldTypes(shared.fieldTypes, BuiltinFieldTypes)
+}
+
 func (shared *APIClientWrapper) ReloadConfig(ctx context.Context) error {
 	shared.lock.Lock()
 	defer shared.lock.Unlock()
@@ -231,6 +271,7 @@ func (shared *APIClientWrapper) reloadConfig(ctx context.Context) error {
 	}
 
 	shared.discovery = memory.NewMemCacheClient(innerDiscovery)
+	shared.fieldTypes = nil
 
 	shared.dynamic, err = dynamic.NewForConfig(shared.restConfig)
 	if err != nil {
@@ -0,0 +1,200 @@
+package kube
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// YamlError is an error at a position in a file or text. Line is the line in the whole file or
+// text, DocumentLine is the line the document starts at, when the error is in a document of a
+// stream.
+type YamlError struct {
+	Line         int
+	Column       int
+	DocumentLine int
+	Message      string
+}
+
+func (e *YamlError) Error() string {
+	if e.DocumentLine > 0 {
+		return fmt.Sprintf("document at line %d, line %d column %d of the document: %s", e.DocumentLine, e.Line-e.DocumentLine+1, e.Column, e.Message)
+	}
+	return fmt.Sprintf("line %d column %d: %s", e.Line, e.Column, e.Message)
+}
+
+var metadataFields = []string{
+	"name", "generateName", "namespace", "selfLink", "uid", "resourceVersion", "generation",
+	"creationTimestamp", "deletionTimestamp", "deletionGracePeriodSeconds", "labels", "annotations",
+	"ownerReferences", "finalizers", "managedFields",
+}
+
+var specAndStatus = []string{"spec", "status"}
+
+// builtinTopLevelFields lists the fields other than apiVersion, kind and metadata which built-in
+// kinds allow, keyed by kind.group. Kinds which are not listed are not checked.
+var builtinTopLevelFields = map[string][]string{
+	"ConfigMap":             {"data", "binaryData", "immutable"},
+	"Secret":                {"data", "stringData", "type", "immutable"},
+	"ServiceAccount":        {"secrets", "imagePullSecrets", "automountServiceAccountToken"},
+	"Endpoints":             {"subsets"},
+	"Namespace":             specAndStatus,
+	"Service":               specAndStatus,
+	"Pod":                   specAndStatus,
+	"PersistentVolume":      specAndStatus,
+	"PersistentVolumeClaim": specAndStatus,
+	"ResourceQuota":         specAndStatus,
+	"LimitRange":            {"spec"},
+	"Deployment.apps":       specAndStatus,
+	"StatefulSet.apps":      specAndStatus,
+	"DaemonSet.apps":        specAndStatus,
+	"ReplicaSet.apps":       specAndStatus,
+	"Job.batch":             specAndStatus,
+	"CronJob.batch":         specAndStatus,
+
+	"HorizontalPodAutoscaler.autoscaling":           specAndStatus,
+	"PodDisruptionBudget.policy":                    specAndStatus,
+	"Ingress.networking.k8s.io":                     specAndStatus,
+	"IngressClass.networking.k8s.io":                {"spec"},
+	"NetworkPolicy.networking.k8s.io":               specAndStatus,
+	"Role.rbac.authorization.k8s.io":                {"rules"},
+	"ClusterRole.rbac.authorization.k8s.io":         {"rules", "aggregationRule"},
+	"RoleBinding.rbac.authorization.k8s.io":         {"subjects", "roleRef"},
+	"ClusterRoleBinding.rbac.authorization.k8s.io":  {"subjects", "roleRef"},
+	"PriorityClass.scheduling.k8s.io":               {"value", "globalDefault", "description", "preemptionPolicy"},
+	"CustomResourceDefinition.apiextensions.k8s.io": specAndStatus,
+	"APIService.apiregistration.k8s.io":             specAndStatus,
+	"StorageClass.storage.k8s.io": {
+		"provisioner", "parameters", "reclaimPolicy", "mountOptions", "allowVolumeExpansion",
+		"volumeBindingMode", "allowedTopologies",
+	},
+	"MutatingWebhookConfiguration.admissionregistration.k8s.io":   {"webhooks"},
+	"ValidatingWebhookConfiguration.admissionregistration.k8s.io": {"webhooks"},
+}
+
+func checkDuplicateKeys(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.MappingNode:
+		seen := make(map[string]*yaml.Node)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i]
+			if key.Value != "<<" {
+				if first, exists := seen[key.Value]; exists {
+					return &YamlError{
+						Line:    key.Line,
+						Column:  key.Column,
+						Message: fmt.Sprintf("duplicate key %q, first defined at line %d", key.Value, first.Line),
+					}
+				}
+				seen[key.Value] = key
+			}
+			err := checkDuplicateKeys(node.Content[i+1])
+			if err != nil {
+				return err
+			}
+		}
+	case yaml.SequenceNode, yaml.DocumentNode:
+		for _, item := range node.Content {
+			err := checkDuplicateKeys(item)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func checkKnownFields(root *yaml.Node) error {
+	var apiVersion, kind string
+	if v := yamlMappingValue(root, "apiVersion"); v != nil {
+		apiVersion = v.Value
+	}
+	if v := yamlMappingValue(root, "kind"); v != nil {
+		kind = v.Value
+	}
+	key := kind
+	if group, _, found := strings.Cut(apiVersion, "/"); found {
+		key = kind + "." + group
+	}
+	allowed, known := builtinTopLevelFields[key]
+	for i := 0; known && i+1 < len(root.Content); i += 2 {
+		field := root.Content[i]
+		switch field.Value {
+		case "apiVersion", "kind", "metadata":
+			continue
+		}
+		if !slices.Contains(allowed, field.Value) {
+			return &YamlError{Line: field.Line, Column: field.Column, Message: fmt.Sprintf("unknown field %q for %s", field.Value, key)}
+		}
+	}
+	metadata := yamlMappingValue(root, "metadata")
+	if metadata == nil || metadata.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(metadata.Content); i += 2 {
+		field := metadata.Content[i]
+		if !slices.Contains(metadataFields, field.Value) {
+			return &YamlError{Line: field.Line, Column: field.Column, Message: fmt.Sprintf("unknown field \"metadata.%s\"", field.Value)}
+		}
+	}
+	return nil
+}
+
+func checkStrictNode(node *yaml.Node) error {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	err := checkDuplicateKeys(node)
+	if err != nil {
+		return err
+	}
+	return checkKnownFields(node)
+}
+
+// checkStrictDocument runs the strict checks on the original text of a document from
+// DecodeYamlStream, so the lines of errors are lines of the whole stream rather than of the
+// re-encoded document.
+func checkStrictDocument(document YamlDocument) error {
+	var err error
+	offset := 0
+	if document.node != nil {
+		err = checkStrictNode(document.node)
+	} else {
+		// json documents keep their original text, which starts at LineNo
+		var root yaml.Node
+		err = yaml.Unmarshal(document.Content, &root)
+		if err != nil || len(root.Content) == 0 {
+			return err
+		}
+		err = checkStrictNode(root.Content[0])
+		offset = document.LineNo - 1
+	}
+	var yamlErr *YamlError
+	if errors.As(err, &yamlErr) {
+		yamlErr.Line += offset
+		yamlErr.DocumentLine = document.LineNo
+	}
+	return err
+}
+
+// ParseStrictYamlManifest parses a single document like ParseSingleYamlManifest, but rejects
+// duplicate keys and unknown metadata fields, and unknown top level fields of built-in kinds.
+// Lines in errors are lines of content.
+func ParseStrictYamlManifest(content string) (unstructured.Unstructured, error) {
+	var node yaml.Node
+	err := yaml.Unmarshal([]byte(content), &node)
+	if err != nil {
+		return unstructured.Unstructured{}, err
+	}
+	if len(node.Content) > 0 {
+		err = checkStrictNode(node.Content[0])
+		if err != nil {
+			return unstructured.Unstructured{}, err
+		}
+	}
+	return ParseSingleYamlManifest(content)
+}
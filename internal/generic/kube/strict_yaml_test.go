@@ -0,0 +1,118 @@
+package kube
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseStrictYamlManifest(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected *YamlError
+	}{
+		{
+			name:  "valid",
+			input: "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: a\ndata:\n  a: b\n",
+		},
+		{
+			name:     "duplicate key",
+			input:    "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: a\ndata:\n  a: b\n  a: c\n",
+			expected: &YamlError{Line: 7, Column: 3},
+		},
+		{
+			name:     "unknown top level field",
+			input:    "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: a\nspce: {}\n",
+			expected: &YamlError{Line: 5, Column: 1},
+		},
+		{
+			name:     "unknown metadata field",
+			input:    "apiVersion: example.com/v1\nkind: Widget\nmetadata:\n  name: a\n  lables: {}\n",
+			expected: &YamlError{Line: 5, Column: 3},
+		},
+		{
+			name:  "custom resource top level fields",
+			input: "apiVersion: example.com/v1\nkind: Widget\nmetadata:\n  name: a\nsettings: {}\n",
+		},
+		{
+			name:     "json duplicate key",
+			input:    "{\"apiVersion\": \"v1\", \"kind\": \"ConfigMap\",\n \"kind\": \"Secret\"}",
+			expected: &YamlError{Line: 2, Column: 2},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParseStrictYamlManifest(tc.input)
+			if tc.expected == nil {
+				if err != nil {
+					t.Fatalf("unexpected error %v", err)
+				}
+				return
+			}
+			var yamlErr *YamlError
+			if !errors.As(err, &yamlErr) {
+				t.Fatalf("expected a YamlError, got %v", err)
+			}
+			if yamlErr.Line != tc.expected.Line || yamlErr.Column != tc.expected.Column || yamlErr.DocumentLine != tc.expected.DocumentLine {
+				t.Errorf("expected line %d column %d of the document at line %d, got %v", tc.expected.Line, tc.expected.Column, tc.expected.DocumentLine, yamlErr)
+			}
+		})
+	}
+}
+
+func TestStrictFileSetLines(t *testing.T) {
+	yamlStream := "# settings\n" +
+		"---\n" +
+		"apiVersion: v1\n" +
+		"kind: ConfigMap\n" +
+		"\n" +
+		"# the name\n" +
+		"metadata:\n" +
+		"  name: a\n" +
+		"\n" +
+		"data:\n" +
+		"  # first\n" +
+		"  a: b\n" +
+		"\n" +
+		"  a: c\n"
+	jsonStream := "{\"apiVersion\": \"v1\", \"kind\": \"ConfigMap\", \"metadata\": {\"name\": \"a\"}}\n" +
+		"\n" +
+		"{\"apiVersion\": \"v1\",\n" +
+		" \"kind\": \"ConfigMap\",\n" +
+		" \"kind\": \"Secret\"}\n"
+	testCases := []struct {
+		filename string
+		content  string
+		expected YamlError
+	}{
+		{"a.yaml", yamlStream, YamlError{Line: 14, Column: 3, DocumentLine: 3}},
+		{"a.json", jsonStream, YamlError{Line: 5, Column: 2, DocumentLine: 3}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.filename, func(t *testing.T) {
+			dir := t.TempDir()
+			err := os.WriteFile(filepath.Join(dir, tc.filename), []byte(tc.content), 0o644)
+			if err != nil {
+				t.Fatal(err)
+			}
+			fsd := FileSetDef{GlobPaths: []string{filepath.Join(dir, tc.filename)}, SplitYamlDocs: true, Strict: true}
+			var handler ExpandedContentHandlerFunc = func(ec *ExpandedContent) error {
+				return nil
+			}
+			err = fsd.ExpandContent(handler)
+			var yamlErr *YamlError
+			if !errors.As(err, &yamlErr) {
+				t.Fatalf("expected a YamlError, got %v", err)
+			}
+			if yamlErr.Line != tc.expected.Line || yamlErr.Column != tc.expected.Column || yamlErr.DocumentLine != tc.expected.DocumentLine {
+				t.Errorf("expected line %d column %d of the document at line %d, got %v", tc.expected.Line, tc.expected.Column, tc.expected.DocumentLine, yamlErr)
+			}
+			if !strings.Contains(err.Error(), "document at line 3, line") {
+				t.Errorf("expected the document start in %q", err.Error())
+			}
+		})
+	}
+}
@@ -7,6 +7,8 @@ import (
 )
 
 type StringMap struct {
+	// PathKeys allows keys to contain "/" so relative paths can be used as keys.
+	PathKeys          bool
 	textData          map[string]string
 	base64EncodedData map[string]string
 }
@@ -21,6 +23,9 @@ func (sm *StringMap) validateUniqueKey(key string) error {
 		return fmt.Errorf("key is empty")
 	}
 	for _, badChar := range "/\\?%*:|\"<>\n\r\t\b\f" {
+		if badChar == '/' && sm.PathKeys {
+			continue
+		}
 		if strings.ContainsRune(key, badChar) {
 			return fmt.Errorf("key %q contains %q", key, badChar)
 		}
@@ -0,0 +1,222 @@
+package kube
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"os"
+
+	"golang.org/x/crypto/ssh"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+type TLSSecretOptions struct {
+	CertFile string
+	KeyFile  string
+	CAFile   string
+}
+
+type DockerRegistryCredential struct {
+	Server   string
+	Username string
+	Password string
+	Email    string
+}
+
+type BasicAuthOptions struct {
+	Username string
+	Password string
+}
+
+type SSHAuthOptions struct {
+	PrivateKeyFile string
+}
+
+// TypedSecret builds a Secret of one of the built-in types from local inputs. Exactly one of
+// the inputs must be set.
+type TypedSecret struct {
+	Name           string
+	Namespace      string
+	Labels         map[string]string
+	Annotations    map[string]string
+	TLS            *TLSSecretOptions
+	DockerRegistry []DockerRegistryCredential
+	BasicAuth      *BasicAuthOptions
+	SSHAuth        *SSHAuthOptions
+}
+
+func parseCertificates(filename string, content []byte) ([]*x509.Certificate, error) {
+	var certificates []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, content = pem.Decode(content)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		certificate, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing certificate in %s: %w", filename, err)
+		}
+		certificates = append(certificates, certificate)
+	}
+	if len(certificates) == 0 {
+		return nil, fmt.Errorf("no certificates found in %s", filename)
+	}
+	return certificates, nil
+}
+
+// Data validates that the key matches the first certificate and that each certificate in the
+// chain is signed by the next one, or by the CA when it is set.
+func (o *TLSSecretOptions) Data() (map[string][]byte, error) {
+	cert, err := os.ReadFile(o.CertFile)
+	if err != nil {
+		return nil, err
+	}
+	key, err := os.ReadFile(o.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	_, err = tls.X509KeyPair(cert, key)
+	if err != nil {
+		return nil, fmt.Errorf("%s does not match %s: %w", o.KeyFile, o.CertFile, err)
+	}
+	chain, err := parseCertificates(o.CertFile, cert)
+	if err != nil {
+		return nil, err
+	}
+	data := map[string][]byte{
+		"tls.crt": cert,
+		"tls.key": key,
+	}
+	if o.CAFile != "" {
+		ca, err := os.ReadFile(o.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		roots, err := parseCertificates(o.CAFile, ca)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, roots[0])
+		data["ca.crt"] = ca
+	}
+	for i := 0; i+1 < len(chain); i++ {
+		err = chain[i].CheckSignatureFrom(chain[i+1])
+		if err != nil {
+			return nil, fmt.Errorf("certificate %q is not signed by %q: %w", chain[i].Subject, chain[i+1].Subject, err)
+		}
+	}
+	return data, nil
+}
+
+// DockerConfigJSON returns the content of a .dockerconfigjson key for the credentials.
+func DockerConfigJSON(credentials []DockerRegistryCredential) ([]byte, error) {
+	auths := make(map[string]any)
+	for _, credential := range credentials {
+		if credential.Server == "" {
+			return nil, fmt.Errorf("server is empty")
+		}
+		if _, found := auths[credential.Server]; found {
+			return nil, fmt.Errorf("duplicate server %s", credential.Server)
+		}
+		if credential.Username == "" || credential.Password == "" {
+			return nil, fmt.Errorf("username and password are required for %s", credential.Server)
+		}
+		auth := map[string]string{
+			"username": credential.Username,
+			"password": credential.Password,
+			"auth":     base64.StdEncoding.EncodeToString([]byte(credential.Username + ":" + credential.Password)),
+		}
+		if credential.Email != "" {
+			auth["email"] = credential.Email
+		}
+		auths[credential.Server] = auth
+	}
+	return json.Marshal(map[string]any{"auths": auths})
+}
+
+func (o *SSHAuthOptions) Data() (map[string][]byte, error) {
+	key, err := os.ReadFile(o.PrivateKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	_, err = ssh.ParseRawPrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing private key %s: %w", o.PrivateKeyFile, err)
+	}
+	return map[string][]byte{"ssh-privatekey": key}, nil
+}
+
+func (s *TypedSecret) typeAndData() (string, map[string][]byte, error) {
+	count := 0
+	for _, set := range []bool{s.TLS != nil, len(s.DockerRegistry) > 0, s.BasicAuth != nil, s.SSHAuth != nil} {
+		if set {
+			count++
+		}
+	}
+	if count != 1 {
+		return "", nil, fmt.Errorf("exactly one of tls, docker_registry, basic_auth or ssh_auth must be set")
+	}
+	switch {
+	case s.TLS != nil:
+		data, err := s.TLS.Data()
+		return "kubernetes.io/tls", data, err
+	case len(s.DockerRegistry) > 0:
+		config, err := DockerConfigJSON(s.DockerRegistry)
+		return "kubernetes.io/dockerconfigjson", map[string][]byte{".dockerconfigjson": config}, err
+	case s.BasicAuth != nil:
+		if s.BasicAuth.Username == "" && s.BasicAuth.Password == "" {
+			return "", nil, fmt.Errorf("basic_auth requires a username or a password")
+		}
+		data := make(map[string][]byte)
+		if s.BasicAuth.Username != "" {
+			data["username"] = []byte(s.BasicAuth.Username)
+		}
+		if s.BasicAuth.Password != "" {
+			data["password"] = []byte(s.BasicAuth.Password)
+		}
+		return "kubernetes.io/basic-auth", data, nil
+	default:
+		data, err := s.SSHAuth.Data()
+		return "kubernetes.io/ssh-auth", data, err
+	}
+}
+
+func (s *TypedSecret) Build() (unstructured.Unstructured, error) {
+	u := unstructured.Unstructured{Object: map[string]any{}}
+	if s.Name == "" {
+		return u, fmt.Errorf("name is empty")
+	}
+	secretType, data, err := s.typeAndData()
+	if err != nil {
+		return u, err
+	}
+	sm := StringMap{}
+	for key, value := range data {
+		err = sm.EncodeAsBase64AndAdd(key, value)
+		if err != nil {
+			return u, err
+		}
+	}
+	u.SetAPIVersion("v1")
+	u.SetKind("Secret")
+	u.SetName(s.Name)
+	if s.Namespace != "" {
+		u.SetNamespace(s.Namespace)
+	}
+	if len(s.Labels) > 0 {
+		u.SetLabels(s.Labels)
+	}
+	if len(s.Annotations) > 0 {
+		u.SetAnnotations(s.Annotations)
+	}
+	u.Object["type"] = secretType
+	u.Object["data"] = sm.GetUnstructuredBase64()
+	return u, nil
+}
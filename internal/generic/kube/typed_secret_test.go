@@ -0,0 +1,203 @@
+package kube
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func writeTestCertificate(t *testing.T, dir, name string, isCA bool, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  isCA,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+	}
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(dir, name+".crt"), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(dir, name+".key"), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certificate, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return certificate, key
+}
+
+func TestTypedSecretTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca, caKey := writeTestCertificate(t, dir, "ca", true, nil, nil)
+	writeTestCertificate(t, dir, "server", false, ca, caKey)
+	writeTestCertificate(t, dir, "other", true, nil, nil)
+	file := func(name string) string { return filepath.Join(dir, name) }
+
+	testCases := []struct {
+		options TLSSecretOptions
+		err     string
+	}{
+		{options: TLSSecretOptions{CertFile: file("server.crt"), KeyFile: file("server.key"), CAFile: file("ca.crt")}},
+		{options: TLSSecretOptions{CertFile: file("server.crt"), KeyFile: file("server.key")}},
+		{options: TLSSecretOptions{CertFile: file("server.crt"), KeyFile: file("other.key")}, err: "does not match"},
+		{options: TLSSecretOptions{CertFile: file("server.crt"), KeyFile: file("server.key"), CAFile: file("other.crt")}, err: "is not signed by"},
+	}
+	for _, tc := range testCases {
+		s := TypedSecret{Name: "tls", TLS: &tc.options}
+		u, err := s.Build()
+		if tc.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("expected error containing %q, got %v", tc.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if u.Object["type"] != "kubernetes.io/tls" {
+			t.Errorf("unexpected type %v", u.Object["type"])
+		}
+		data := u.Object["data"].(map[string]any)
+		if data["tls.crt"] == nil || data["tls.key"] == nil {
+			t.Errorf("expected tls.crt and tls.key in %v", data)
+		}
+	}
+}
+
+func TestTypedSecretDockerRegistry(t *testing.T) {
+	s := TypedSecret{Name: "pull", DockerRegistry: []DockerRegistryCredential{{Server: "ghcr.io", Username: "me", Password: "secret"}}}
+	u, err := s.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Object["type"] != "kubernetes.io/dockerconfigjson" {
+		t.Errorf("unexpected type %v", u.Object["type"])
+	}
+	config, err := base64.StdEncoding.DecodeString(u.Object["data"].(map[string]any)[".dockerconfigjson"].(string))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"auths":{"ghcr.io":{"auth":"bWU6c2VjcmV0","password":"secret","username":"me"}}}`
+	if string(config) != expected {
+		t.Errorf("expected %s, got %s", expected, config)
+	}
+
+	s.DockerRegistry = append(s.DockerRegistry, DockerRegistryCredential{Server: "ghcr.io", Username: "me", Password: "other"})
+	_, err = s.Build()
+	if err == nil {
+		t.Errorf("expected an error for a duplicate server")
+	}
+}
+
+func TestTypedSecretAuth(t *testing.T) {
+	s := TypedSecret{Name: "basic", BasicAuth: &BasicAuthOptions{Username: "me", Password: "secret"}}
+	u, err := s.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Object["type"] != "kubernetes.io/basic-auth" || u.Object["data"].(map[string]any)["username"] != "bWU=" {
+		t.Errorf("unexpected secret %v", u.Object)
+	}
+
+	dir := t.TempDir()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := ssh.MarshalPrivateKey(key, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyFile := filepath.Join(dir, "id_ed25519")
+	err = os.WriteFile(keyFile, pem.EncodeToMemory(block), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s = TypedSecret{Name: "ssh", SSHAuth: &SSHAuthOptions{PrivateKeyFile: keyFile}}
+	u, err = s.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Object["type"] != "kubernetes.io/ssh-auth" || u.Object["data"].(map[string]any)["ssh-privatekey"] == nil {
+		t.Errorf("unexpected secret %v", u.Object)
+	}
+
+	s.BasicAuth = &BasicAuthOptions{Username: "me"}
+	_, err = s.Build()
+	if err == nil {
+		t.Errorf("expected an error when more than one input is set")
+	}
+}
+
+func TestTypedSecretLiveHash(t *testing.T) {
+	s := TypedSecret{Name: "basic", BasicAuth: &BasicAuthOptions{Username: "me", Password: "secret"}}
+	u, err := s.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	built, err := ContentHash(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the live object has the same content as decoded json, along with fields set by the server
+	text, err := json.Marshal(u.Object)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var live unstructured.Unstructured
+	err = json.Unmarshal(text, &live.Object)
+	if err != nil {
+		t.Fatal(err)
+	}
+	live.SetResourceVersion("42")
+	liveHash, err := ContentHash(live)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if liveHash != built {
+		t.Errorf("expected the live hash %s to match the built hash %s", liveHash, built)
+	}
+	live.Object["data"].(map[string]any)["password"] = "Y2hhbmdlZA=="
+	changed, err := ContentHash(live)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed == built {
+		t.Errorf("expected a changed value to change the hash")
+	}
+}
@@ -3,6 +3,7 @@ package kube
 import (
 	"fmt"
 	"os"
+	"sort"
 	"strings"
 
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -60,3 +61,40 @@ func ParseSingleYamlManifest(content string) (unstructured.Unstructured, error)
 	}
 	return obj, nil
 }
+
+// DeepMerge merges src into dst. Maps are merged key by key and any other value in src
+// replaces the value in dst.
+func DeepMerge(dst, src map[string]any) {
+	for key, value := range src {
+		srcMap, srcIsMap := value.(map[string]any)
+		dstMap, dstIsMap := dst[key].(map[string]any)
+		if srcIsMap && dstIsMap {
+			DeepMerge(dstMap, srcMap)
+			continue
+		}
+		dst[key] = value
+	}
+}
+
+// OverlappingPaths returns the dotted paths which both a and b set, where DeepMerge would replace
+// the value of a with the value of b rather than merge them.
+func OverlappingPaths(a, b map[string]any) []string {
+	var paths []string
+	for key, value := range b {
+		existing, found := a[key]
+		if !found {
+			continue
+		}
+		bMap, bIsMap := value.(map[string]any)
+		aMap, aIsMap := existing.(map[string]any)
+		if aIsMap && bIsMap {
+			for _, path := range OverlappingPaths(aMap, bMap) {
+				paths = append(paths, key+"."+path)
+			}
+			continue
+		}
+		paths = append(paths, key)
+	}
+	sort.Strings(paths)
+	return paths
+}
@@ -0,0 +1,63 @@
+package kube
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDeepMerge(t *testing.T) {
+	dst := map[string]any{
+		"kind":     "Secret",
+		"metadata": map[string]any{"name": "s", "labels": map[string]any{"a": "1"}},
+		"data":     map[string]any{"user": "dXNlcg=="},
+		"list":     []any{"a"},
+	}
+	src := map[string]any{
+		"metadata": map[string]any{"labels": map[string]any{"b": "2"}},
+		"data":     map[string]any{"password": "c2VjcmV0"},
+		"list":     []any{"b"},
+	}
+	DeepMerge(dst, src)
+	expected := map[string]any{
+		"kind":     "Secret",
+		"metadata": map[string]any{"name": "s", "labels": map[string]any{"a": "1", "b": "2"}},
+		"data":     map[string]any{"user": "dXNlcg==", "password": "c2VjcmV0"},
+		"list":     []any{"b"},
+	}
+	if !reflect.DeepEqual(dst, expected) {
+		t.Errorf("expected %v, got %v", expected, dst)
+	}
+}
+
+func TestOverlappingPaths(t *testing.T) {
+	testCases := []struct {
+		name     string
+		a        map[string]any
+		b        map[string]any
+		expected []string
+	}{
+		{
+			name: "disjoint",
+			a:    map[string]any{"metadata": map[string]any{"name": "a"}, "data": map[string]any{"user": "u"}},
+			b:    map[string]any{"data": map[string]any{"password": "p"}},
+		},
+		{
+			name:     "leaf",
+			a:        map[string]any{"spec": map[string]any{"caBundle": "a", "url": "u"}},
+			b:        map[string]any{"spec": map[string]any{"caBundle": "b"}},
+			expected: []string{"spec.caBundle"},
+		},
+		{
+			name:     "map and value",
+			a:        map[string]any{"spec": map[string]any{"token": map[string]any{"value": "a"}}, "list": []any{"a"}},
+			b:        map[string]any{"spec": map[string]any{"token": "b"}, "list": []any{"b"}},
+			expected: []string{"list", "spec.token"},
+		},
+	}
+	for _, tc := range testCases {
+		paths := OverlappingPaths(tc.a, tc.b)
+		if !reflect.DeepEqual(paths, tc.expected) {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.expected, paths)
+		}
+	}
+}
@@ -0,0 +1,256 @@
+package kube
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// YamlDocument is a single kubernetes object read from a yaml or json stream. LineNo is the line
+// in the stream where the object starts. Content is the text as written, except for the items of
+// a List which are encoded on their own.
+type YamlDocument struct {
+	LineNo  int
+	Content []byte
+
+	// node is the original yaml of the document, which still has the lines of the stream.
+	node *yaml.Node
+}
+
+func isListKind(kind string) bool {
+	return strings.HasSuffix(kind, "List")
+}
+
+// DecodeYamlStream splits a yaml stream ( or a stream of json values when the filename ends in
+// .json ) into documents. List kinds are expanded into their items and empty documents are skipped.
+func DecodeYamlStream(filename string, content []byte) ([]YamlDocument, error) {
+	if strings.ToLower(filepath.Ext(filename)) == ".json" {
+		return decodeJsonStream(filename, content)
+	}
+	var documents []YamlDocument
+	offsets := lineOffsets(content)
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	for {
+		var node yaml.Node
+		err := decoder.Decode(&node)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filename, err)
+		}
+		if len(node.Content) == 0 {
+			continue
+		}
+		items, err := expandListNode(node.Content[0])
+		if err != nil {
+			return nil, fmt.Errorf("%s line %d: %w", filename, node.Content[0].Line, err)
+		}
+		if len(items) == 1 && items[0] == node.Content[0] {
+			// not a List, so the document is handed on as it was written
+			item := items[0]
+			documents = append(documents, YamlDocument{LineNo: item.Line, Content: documentText(content, offsets, item), node: item})
+			continue
+		}
+		for _, item := range items {
+			// items are encoded on their own, so aliases to anchors outside of them are resolved
+			content, err := encodeYamlNode(resolveAliases(item))
+			if err != nil {
+				return nil, fmt.Errorf("%s line %d: %w", filename, item.Line, err)
+			}
+			documents = append(documents, YamlDocument{LineNo: item.Line, Content: content, node: item})
+		}
+	}
+	return documents, nil
+}
+
+func lineOffsets(content []byte) []int {
+	offsets := []int{0}
+	for i, c := range content {
+		if c == '\n' {
+			offsets = append(offsets, i+1)
+		}
+	}
+	return offsets
+}
+
+func isDocumentMarker(line []byte) bool {
+	for _, marker := range []string{"---", "..."} {
+		if bytes.HasPrefix(line, []byte(marker)) && (len(line) == 3 || strings.ContainsRune(" \t\r\n", rune(line[3]))) {
+			return true
+		}
+	}
+	return false
+}
+
+// documentText slices the text of the document which starts with node, up to the next document
+// marker, so its comments, quoting and indentation are kept. Content on the line of a `---`
+// starts at the node.
+func documentText(content []byte, offsets []int, node *yaml.Node) []byte {
+	lineStart := offsets[node.Line-1]
+	start := lineStart + node.Column - 1
+	if len(bytes.TrimSpace(content[lineStart:start])) == 0 {
+		start = lineStart
+	}
+	end := len(content)
+	for line := node.Line; line < len(offsets); line++ {
+		lineEnd := len(content)
+		if line+1 < len(offsets) {
+			lineEnd = offsets[line+1]
+		}
+		if isDocumentMarker(content[offsets[line]:lineEnd]) {
+			end = offsets[line]
+			break
+		}
+	}
+	text := bytes.TrimRight(content[start:end], " \t\r\n")
+	return append(append([]byte{}, text...), '\n')
+}
+
+// resolveAliases returns a copy of node with each alias replaced by a copy of its anchor.
+func resolveAliases(node *yaml.Node) *yaml.Node {
+	if node == nil {
+		return nil
+	}
+	if node.Kind == yaml.AliasNode {
+		return resolveAliases(node.Alias)
+	}
+	resolved := *node
+	resolved.Anchor = ""
+	resolved.Content = make([]*yaml.Node, len(node.Content))
+	for i, child := range node.Content {
+		resolved.Content[i] = resolveAliases(child)
+	}
+	return &resolved
+}
+
+func yamlMappingValue(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func expandListNode(node *yaml.Node) ([]*yaml.Node, error) {
+	switch {
+	case node.Kind == yaml.ScalarNode && node.Tag == "!!null":
+		return nil, nil
+	case node.Kind != yaml.MappingNode:
+		return nil, fmt.Errorf("expected a mapping, got %s", node.ShortTag())
+	}
+	kind := yamlMappingValue(node, "kind")
+	items := yamlMappingValue(node, "items")
+	if kind == nil || !isListKind(kind.Value) || items == nil || items.Kind != yaml.SequenceNode {
+		return []*yaml.Node{node}, nil
+	}
+	var result []*yaml.Node
+	for _, item := range items.Content {
+		expanded, err := expandListNode(item)
+		if err != nil {
+			return nil, fmt.Errorf("%s line %d: %w", kind.Value, item.Line, err)
+		}
+		result = append(result, expanded...)
+	}
+	return result, nil
+}
+
+func encodeYamlNode(node *yaml.Node) ([]byte, error) {
+	buffer := &bytes.Buffer{}
+	encoder := yaml.NewEncoder(buffer)
+	encoder.SetIndent(2)
+	err := encoder.Encode(node)
+	if err != nil {
+		return nil, err
+	}
+	err = encoder.Close()
+	if err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+func isListObject(v map[string]any) bool {
+	kind, _ := v["kind"].(string)
+	_, hasItems := v["items"].([]any)
+	return hasItems && isListKind(kind)
+}
+
+// expandListItems returns the items of a List kind, or the object itself for any other kind.
+func expandListItems(v any) ([]map[string]any, error) {
+	switch v := v.(type) {
+	case map[string]any:
+		if !isListObject(v) {
+			return []map[string]any{v}, nil
+		}
+		kind := v["kind"].(string)
+		var result []map[string]any
+		for _, item := range v["items"].([]any) {
+			expanded, err := expandListItems(item)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", kind, err)
+			}
+			result = append(result, expanded...)
+		}
+		return result, nil
+	case nil:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("expected an object, got %T", v)
+	}
+}
+
+func lineAtOffset(content []byte, offset int64) int {
+	for offset < int64(len(content)) && strings.ContainsRune(" \t\r\n", rune(content[offset])) {
+		offset++
+	}
+	return bytes.Count(content[:offset], []byte("\n")) + 1
+}
+
+func decodeJsonStream(filename string, content []byte) ([]YamlDocument, error) {
+	var documents []YamlDocument
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+	for {
+		lineNo := lineAtOffset(content, decoder.InputOffset())
+		var raw json.RawMessage
+		err := decoder.Decode(&raw)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s line %d: %w", filename, lineNo, err)
+		}
+		var value any
+		valueDecoder := json.NewDecoder(bytes.NewReader(raw))
+		valueDecoder.UseNumber()
+		err = valueDecoder.Decode(&value)
+		if err != nil {
+			return nil, fmt.Errorf("%s line %d: %w", filename, lineNo, err)
+		}
+		items, err := expandListItems(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s line %d: %w", filename, lineNo, err)
+		}
+		if object, ok := value.(map[string]any); ok && !isListObject(object) {
+			// keep the original text, so strict parsing still sees duplicate keys
+			documents = append(documents, YamlDocument{LineNo: lineNo, Content: append(raw, '\n')})
+			continue
+		}
+		for _, item := range items {
+			content, err := json.MarshalIndent(item, "", "  ")
+			if err != nil {
+				return nil, fmt.Errorf("%s line %d: %w", filename, lineNo, err)
+			}
+			documents = append(documents, YamlDocument{LineNo: lineNo, Content: append(content, '\n')})
+		}
+	}
+	return documents, nil
+}
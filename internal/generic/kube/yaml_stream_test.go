@@ -0,0 +1,155 @@
+package kube
+
+import (
+	"reflect"
+	"testing"
+
+	"sigs.k8s.io/yaml"
+)
+
+func TestDecodeYamlStream(t *testing.T) {
+	testCases := []struct {
+		name     string
+		filename string
+		input    string
+		lines    []int
+		names    []string
+		err      bool
+	}{
+		{
+			name:     "plain separators",
+			filename: "a.yaml",
+			input:    "kind: ConfigMap\nmetadata:\n  name: a\n---\nkind: ConfigMap\nmetadata:\n  name: b\n",
+			lines:    []int{1, 5},
+			names:    []string{"a", "b"},
+		},
+		{
+			name:     "separator with comment and empty documents",
+			filename: "a.yaml",
+			input:    "--- # first\nkind: ConfigMap\nmetadata:\n  name: a\n--- # empty\n---\n# only a comment\n---\nkind: ConfigMap\nmetadata:\n  name: b\n",
+			lines:    []int{2, 9},
+			names:    []string{"a", "b"},
+		},
+		{
+			name:     "content after separator and terminator",
+			filename: "a.yml",
+			input:    "--- {kind: ConfigMap, metadata: {name: a}}\n...\n---\nkind: ConfigMap\nmetadata:\n  name: b\n...\n",
+			lines:    []int{1, 4},
+			names:    []string{"a", "b"},
+		},
+		{
+			name:     "list",
+			filename: "a.yaml",
+			input:    "apiVersion: v1\nkind: List\nitems:\n- kind: ConfigMap\n  metadata:\n    name: a\n- kind: ConfigMap\n  metadata:\n    name: b\n",
+			lines:    []int{4, 7},
+			names:    []string{"a", "b"},
+		},
+		{
+			name:     "typed list",
+			filename: "a.yaml",
+			input:    "apiVersion: v1\nkind: ConfigMapList\nitems:\n  - kind: ConfigMap\n    metadata:\n      name: a\n",
+			lines:    []int{4},
+			names:    []string{"a"},
+		},
+		{
+			name:     "json stream",
+			filename: "a.json",
+			input:    "{\"kind\": \"ConfigMap\", \"metadata\": {\"name\": \"a\"}}\n\n{\"kind\": \"List\", \"items\": [{\"kind\": \"ConfigMap\", \"metadata\": {\"name\": \"b\"}}]}\n",
+			lines:    []int{1, 3},
+			names:    []string{"a", "b"},
+		},
+		{
+			name:     "scalar document",
+			filename: "a.yaml",
+			input:    "garbage\n",
+			err:      true,
+		},
+		{
+			name:     "invalid yaml",
+			filename: "a.yaml",
+			input:    "kind: [\n",
+			err:      true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			documents, err := DecodeYamlStream(tc.filename, []byte(tc.input))
+			if (err != nil) != tc.err {
+				t.Fatalf("expected error %v, got %v", tc.err, err)
+			}
+			var lines []int
+			var names []string
+			for _, document := range documents {
+				var obj struct {
+					Metadata struct {
+						Name string `json:"name"`
+					} `json:"metadata"`
+				}
+				err = yaml.Unmarshal(document.Content, &obj)
+				if err != nil {
+					t.Fatalf("document at line %d: %v", document.LineNo, err)
+				}
+				lines = append(lines, document.LineNo)
+				names = append(names, obj.Metadata.Name)
+			}
+			if !reflect.DeepEqual(lines, tc.lines) {
+				t.Errorf("expected lines %v, got %v", tc.lines, lines)
+			}
+			if !reflect.DeepEqual(names, tc.names) {
+				t.Errorf("expected names %v, got %v", tc.names, names)
+			}
+		})
+	}
+}
+
+func TestDecodeYamlStreamText(t *testing.T) {
+	input := "# leading comment\n---\nkind: ConfigMap # the kind\nmetadata:\n    name: 'a'\n\ndata:\n    port: \"80\"\n--- {kind: ConfigMap, metadata: {name: b}}\n"
+	documents, err := DecodeYamlStream("a.yaml", []byte(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"kind: ConfigMap # the kind\nmetadata:\n    name: 'a'\n\ndata:\n    port: \"80\"\n",
+		"{kind: ConfigMap, metadata: {name: b}}\n",
+	}
+	if len(documents) != len(expected) {
+		t.Fatalf("expected %d documents, got %d", len(expected), len(documents))
+	}
+	for i, document := range documents {
+		if string(document.Content) != expected[i] {
+			t.Errorf("expected document %d to keep its text %q, got %q", i, expected[i], document.Content)
+		}
+	}
+}
+
+func TestDecodeYamlStreamListAnchors(t *testing.T) {
+	input := `apiVersion: v1
+kind: List
+items:
+- kind: ConfigMap
+  metadata:
+    name: a
+    labels: &labels
+      app: web
+- kind: ConfigMap
+  metadata:
+    name: b
+    labels: *labels
+`
+	documents, err := DecodeYamlStream("a.yaml", []byte(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(documents) != 2 {
+		t.Fatalf("expected 2 documents, got %d", len(documents))
+	}
+	for _, document := range documents {
+		u, err := ParseSingleYamlManifest(string(document.Content))
+		if err != nil {
+			t.Fatalf("document at line %d: %v\n%s", document.LineNo, err, document.Content)
+		}
+		if u.GetLabels()["app"] != "web" {
+			t.Errorf("expected %s to have the shared labels, got %v", u.GetName(), u.GetLabels())
+		}
+	}
+}
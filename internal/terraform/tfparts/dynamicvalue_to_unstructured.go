@@ -3,76 +3,212 @@ package tfparts
 import (
 	"context"
 	"fmt"
+	"math/big"
 	"strconv"
 
-	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/davidjspooner/terraform-provider-kubernetes/internal/generic/kube"
 	"github.com/hashicorp/terraform-plugin-framework/types"
-	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	runtimeschema "k8s.io/apimachinery/pkg/runtime/schema"
 )
 
+// ManifestConverter converts a terraform value to a manifest, using FieldTypes to decide the type
+// of each value. Values of fields with an unknown type are guessed from their text.
+type ManifestConverter struct {
+	// FieldTypes defaults to kube.BuiltinFieldTypes
+	FieldTypes kube.FieldTypeResolver
+	// GVK is used when the value has no apiVersion and kind of its own, such as a value which is
+	// merged into another manifest
+	GVK runtimeschema.GroupVersionKind
+	// NoGuess keeps strings of fields with an unknown type as strings
+	NoGuess bool
+	// AllowUnknown converts values which are not known yet to Unknown instead of failing
+	AllowUnknown bool
+}
+
 func DynamicValueToUnstructured(ctx context.Context, value types.Dynamic) (unstructured.Unstructured, error) {
-	if value.IsNull() || value.IsUnknown() {
+	return ManifestConverter{}.ToUnstructured(ctx, value)
+}
+
+// ToUnstructured accepts any value which converts to an object, including objects and maps from
+// merge() and tomap(). A null or unknown value gives an empty manifest.
+func (c ManifestConverter) ToUnstructured(ctx context.Context, value types.Dynamic) (unstructured.Unstructured, error) {
+	if value.IsNull() || value.IsUnknown() || value.IsUnderlyingValueNull() || value.IsUnderlyingValueUnknown() {
 		return unstructured.Unstructured{}, nil
 	}
+	if c.FieldTypes == nil {
+		c.FieldTypes = kube.BuiltinFieldTypes
+	}
 	var u unstructured.Unstructured
-	innerValue := value.UnderlyingValue()
-	switch innerValue := innerValue.(type) {
-	case basetypes.ObjectValue:
-		// Convert the object value to a map[string]interface{}
-		valueMap := innerValue.Attributes()
-		anyMap, err := convertAttrObjectToAnyMap(ctx, valueMap)
+	tfValue, err := value.ToTerraformValue(ctx)
+	if err != nil {
+		return u, err
+	}
+	switch tfValue.Type().(type) {
+	case tftypes.Object, tftypes.Map:
+	default:
+		return u, fmt.Errorf("unsupported type %s, the manifest must be an object", tfValue.Type())
+	}
+	var attrs map[string]tftypes.Value
+	err = tfValue.As(&attrs)
+	if err != nil {
+		return u, err
+	}
+	c.setGVKFrom(attrs)
+	u.Object, err = c.convertTerraformMap(nil, attrs)
+	if err != nil {
+		return u, fmt.Errorf("failed to convert object value to map: %w", err)
+	}
+	return u, nil
+}
+
+// setGVKFrom uses the apiVersion and kind of the manifest when it has them.
+func (c *ManifestConverter) setGVKFrom(attrs map[string]tftypes.Value) {
+	apiVersionValue, found := attrs["apiVersion"]
+	if !found || !apiVersionValue.IsFullyKnown() {
+		return
+	}
+	kindValue, found := attrs["kind"]
+	if !found || !kindValue.IsFullyKnown() {
+		return
+	}
+	var apiVersion, kind string
+	if apiVersionValue.As(&apiVersion) != nil || kindValue.As(&kind) != nil {
+		return
+	}
+	if apiVersion == "" || kind == "" {
+		return
+	}
+	gv, err := runtimeschema.ParseGroupVersion(apiVersion)
+	if err != nil {
+		return
+	}
+	c.GVK = gv.WithKind(kind)
+}
+
+// convertTerraformValue handles every terraform type. Lists, sets and tuples become lists and
+// maps and objects become maps, so values built with merge(), tomap() and tolist() convert the
+// same way as literals.
+func (c ManifestConverter) convertTerraformValue(path []string, value tftypes.Value) (interface{}, error) {
+	if !value.IsKnown() {
+		if c.AllowUnknown {
+			return Unknown{}, nil
+		}
+		return nil, fmt.Errorf("value is unknown")
+	}
+	if value.IsNull() {
+		return nil, nil
+	}
+	switch valueType := value.Type().(type) {
+	case tftypes.List, tftypes.Set, tftypes.Tuple:
+		var items []tftypes.Value
+		err := value.As(&items)
+		if err != nil {
+			return nil, err
+		}
+		return c.convertTerraformList(path, items)
+	case tftypes.Map, tftypes.Object:
+		var attrs map[string]tftypes.Value
+		err := value.As(&attrs)
 		if err != nil {
-			return u, fmt.Errorf("failed to convert object value to map: %w", err)
+			return nil, err
 		}
-		u.Object = anyMap
+		return c.convertTerraformMap(path, attrs)
 	default:
-		return u, fmt.Errorf("unsupported type %T", innerValue)
+		switch {
+		case valueType.Is(tftypes.String):
+			var s string
+			err := value.As(&s)
+			if err != nil {
+				return nil, err
+			}
+			return c.convertString(path, s), nil
+		case valueType.Is(tftypes.Bool):
+			var b bool
+			err := value.As(&b)
+			if err != nil {
+				return nil, err
+			}
+			if c.FieldTypes.FieldType(c.GVK, path) == kube.FieldTypeString {
+				return strconv.FormatBool(b), nil
+			}
+			return b, nil
+		case valueType.Is(tftypes.Number):
+			bf := new(big.Float)
+			err := value.As(&bf)
+			if err != nil {
+				return nil, err
+			}
+			if c.FieldTypes.FieldType(c.GVK, path) == kube.FieldTypeString {
+				return bf.Text('f', -1), nil
+			}
+			return bigFloatToAny(bf), nil
+		}
+		return nil, fmt.Errorf("unsupported type %s", valueType)
 	}
-	_ = innerValue
+}
 
-	return u, nil
+// bigFloatToAny gives an int64 for whole numbers which fit, like decoding json into unstructured
+// does, and a float64 otherwise.
+func bigFloatToAny(bf *big.Float) interface{} {
+	if bf.IsInt() {
+		n, accuracy := bf.Int64()
+		if accuracy == big.Exact {
+			return n
+		}
+	}
+	f, _ := bf.Float64()
+	return f
 }
 
-func convertAttrValueToAny(ctx context.Context, value attr.Value) (interface{}, error) {
-	switch value := value.(type) {
-	case basetypes.StringValue:
-		s := value.ValueString()
+// convertString keeps the string when the field is a string and otherwise parses it as the
+// type of the field. A string which does not parse is left for the api server to reject.
+func (c ManifestConverter) convertString(path []string, s string) interface{} {
+	switch c.FieldTypes.FieldType(c.GVK, path) {
+	case kube.FieldTypeString:
+		return s
+	case kube.FieldTypeInteger, kube.FieldTypeIntOrString:
 		n, err := strconv.ParseInt(s, 10, 64)
 		if err == nil {
-			return n, nil
+			return n
 		}
-		b, err := strconv.ParseBool(s)
+		return s
+	case kube.FieldTypeNumber:
+		f, err := strconv.ParseFloat(s, 64)
 		if err == nil {
-			return b, nil
+			return f
 		}
-		f, err := strconv.ParseFloat(s, 64)
+		return s
+	case kube.FieldTypeBoolean:
+		b, err := strconv.ParseBool(s)
 		if err == nil {
-			return f, nil
+			return b
 		}
-		return s, nil
-	case basetypes.BoolValue:
-		return value.ValueBool(), nil
-	case basetypes.NumberValue:
-		bf := value.ValueBigFloat()
-		f, _ := bf.Float64()
-		return f, nil
-	case basetypes.TupleValue:
-		typeList := value.ElementTypes(ctx)
-		valueList := value.Elements()
-		return convertAttrTupleToAnyList(typeList, valueList)
-	case basetypes.ObjectValue:
-		elements := value.Attributes()
-		return convertAttrObjectToAnyMap(ctx, elements)
-	default:
-		return nil, fmt.Errorf("unsupported type %T", value)
+		return s
+	}
+	if c.NoGuess {
+		return s
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err == nil {
+		return n
 	}
+	b, err := strconv.ParseBool(s)
+	if err == nil {
+		return b
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err == nil {
+		return f
+	}
+	return s
 }
 
-func convertAttrObjectToAnyMap(ctx context.Context, attrMap map[string]attr.Value) (map[string]interface{}, error) {
-	anyMap := make(map[string]interface{}, len(attrMap))
-	for key, value := range attrMap {
-		anyValue, err := convertAttrValueToAny(ctx, value)
+func (c ManifestConverter) convertTerraformMap(path []string, attrs map[string]tftypes.Value) (map[string]interface{}, error) {
+	anyMap := make(map[string]interface{}, len(attrs))
+	for key, value := range attrs {
+		anyValue, err := c.convertTerraformValue(appendPath(path, key), value)
 		if err != nil {
 			return nil, fmt.Errorf("failed to convert value for key %s: %w", key, err)
 		}
@@ -81,13 +217,11 @@ func convertAttrObjectToAnyMap(ctx context.Context, attrMap map[string]attr.Valu
 	return anyMap, nil
 }
 
-func convertAttrTupleToAnyList(typeList []attr.Type, valueList []attr.Value) ([]interface{}, error) {
-	if len(typeList) != len(valueList) {
-		return nil, fmt.Errorf("typeList and valueList must be the same length")
-	}
-	anyList := make([]interface{}, len(typeList))
-	for i, value := range valueList {
-		anyValue, err := convertAttrValueToAny(context.Background(), value)
+func (c ManifestConverter) convertTerraformList(path []string, items []tftypes.Value) ([]interface{}, error) {
+	itemPath := appendPath(path, kube.ListItem)
+	anyList := make([]interface{}, len(items))
+	for i, value := range items {
+		anyValue, err := c.convertTerraformValue(itemPath, value)
 		if err != nil {
 			return nil, fmt.Errorf("failed to convert value at index %d: %w", i, err)
 		}
@@ -95,3 +229,8 @@ func convertAttrTupleToAnyList(typeList []attr.Type, valueList []attr.Value) ([]
 	}
 	return anyList, nil
 }
+
+// appendPath never shares the backing array of path, so sibling paths stay independent.
+func appendPath(path []string, element string) []string {
+	return append(path[:len(path):len(path)], element)
+}
@@ -0,0 +1,524 @@
+package tfparts
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/davidjspooner/terraform-provider-kubernetes/internal/generic/kube"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	runtimeschema "k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestToUnstructuredAttrTypes(t *testing.T) {
+	ctx := context.Background()
+	bigInt := new(big.Float).SetInt64(math.MaxInt64)
+	value := types.ObjectValueMust(
+		map[string]attr.Type{
+			"apiVersion": types.StringType,
+			"kind":       types.StringType,
+			"spec": types.ObjectType{AttrTypes: map[string]attr.Type{
+				"replicas": types.NumberType,
+				"list":     types.ListType{ElemType: types.StringType},
+				"set":      types.SetType{ElemType: types.NumberType},
+				"map":      types.MapType{ElemType: types.BoolType},
+				"int64":    types.Int64Type,
+				"float64":  types.Float64Type,
+				"big":      types.NumberType,
+				"ratio":    types.NumberType,
+				"nested":   types.DynamicType,
+				"null":     types.StringType,
+				"nothing":  types.DynamicType,
+			}},
+		},
+		map[string]attr.Value{
+			"apiVersion": types.StringValue("apps/v1"),
+			"kind":       types.StringValue("Deployment"),
+			"spec": types.ObjectValueMust(
+				map[string]attr.Type{
+					"replicas": types.NumberType,
+					"list":     types.ListType{ElemType: types.StringType},
+					"set":      types.SetType{ElemType: types.NumberType},
+					"map":      types.MapType{ElemType: types.BoolType},
+					"int64":    types.Int64Type,
+					"float64":  types.Float64Type,
+					"big":      types.NumberType,
+					"ratio":    types.NumberType,
+					"nested":   types.DynamicType,
+					"null":     types.StringType,
+					"nothing":  types.DynamicType,
+				},
+				map[string]attr.Value{
+					"replicas": types.NumberValue(big.NewFloat(3)),
+					"list":     types.ListValueMust(types.StringType, []attr.Value{types.StringValue("a"), types.StringValue("b")}),
+					"set":      types.SetValueMust(types.NumberType, []attr.Value{types.NumberValue(big.NewFloat(1))}),
+					"map":      types.MapValueMust(types.BoolType, map[string]attr.Value{"on": types.BoolValue(true)}),
+					"int64":    types.Int64Value(-42),
+					"float64":  types.Float64Value(0.25),
+					"big":      types.NumberValue(bigInt),
+					"ratio":    types.NumberValue(big.NewFloat(1.5)),
+					"nested":   types.DynamicValue(types.TupleValueMust([]attr.Type{types.StringType}, []attr.Value{types.StringValue("x")})),
+					"null":     types.StringNull(),
+					"nothing":  types.DynamicNull(),
+				},
+			),
+		},
+	)
+	u, err := DynamicValueToUnstructured(ctx, types.DynamicValue(value))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]any{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"spec": map[string]any{
+			"replicas": int64(3),
+			"list":     []any{"a", "b"},
+			"set":      []any{int64(1)},
+			"map":      map[string]any{"on": true},
+			"int64":    int64(-42),
+			"float64":  0.25,
+			"big":      int64(math.MaxInt64),
+			"ratio":    1.5,
+			"nested":   []any{"x"},
+			"null":     nil,
+			"nothing":  nil,
+		},
+	}
+	if !reflect.DeepEqual(u.Object, expected) {
+		t.Errorf("expected %#v, got %#v", expected, u.Object)
+	}
+
+	// tomap() gives a map rather than an object
+	m := types.MapValueMust(types.StringType, map[string]attr.Value{
+		"apiVersion": types.StringValue("v1"),
+		"kind":       types.StringValue("Namespace"),
+	})
+	u, err = DynamicValueToUnstructured(ctx, types.DynamicValue(m))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.GetKind() != "Namespace" {
+		t.Errorf("expected a map to convert, got %#v", u.Object)
+	}
+
+	_, err = DynamicValueToUnstructured(ctx, types.DynamicValue(types.StringValue("x")))
+	if err == nil {
+		t.Errorf("expected an error for a manifest which is not an object")
+	}
+}
+
+const testDeploymentManifest = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: default
+  labels:
+    app: web
+    enabled: "true"
+  annotations:
+    example.com/version: "1.0"
+spec:
+  replicas: 3
+  revisionHistoryLimit: 10
+  selector:
+    matchLabels:
+      app: web
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      terminationGracePeriodSeconds: 30
+      containers:
+      - name: web
+        image: nginx:1.25
+        args: ["--port", "8080"]
+        env:
+        - name: PORT
+          value: "8080"
+        - name: DEBUG
+          value: "false"
+        - name: RATIO
+          value: "0.5"
+        ports:
+        - containerPort: 8080
+          protocol: TCP
+        resources:
+          limits:
+            cpu: "1"
+            memory: 128Mi
+        readinessProbe:
+          httpGet:
+            path: /healthz
+            port: http
+          initialDelaySeconds: 5
+        securityContext:
+          runAsNonRoot: true
+          runAsUser: 1000
+`
+
+const testServiceManifest = `apiVersion: v1
+kind: Service
+metadata:
+  name: web
+spec:
+  selector:
+    app: web
+  ports:
+  - name: http
+    port: 80
+    targetPort: 8080
+  - name: metrics
+    port: 9090
+    targetPort: metrics
+`
+
+const testConfigMapManifest = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+data:
+  port: "5432"
+  enabled: "yes"
+  ratio: "0.75"
+  empty: ""
+`
+
+const testCronJobManifest = `apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: backup
+spec:
+  schedule: "0 3 * * *"
+  successfulJobsHistoryLimit: 1
+  jobTemplate:
+    spec:
+      backoffLimit: 2
+      template:
+        spec:
+          restartPolicy: OnFailure
+          containers:
+          - name: backup
+            image: busybox
+            command: ["sh", "-c", "echo 1"]
+`
+
+const testIngressManifest = `apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  name: web
+  annotations:
+    nginx.ingress.kubernetes.io/proxy-body-size: "0"
+spec:
+  rules:
+  - host: example.com
+    http:
+      paths:
+      - path: /
+        pathType: Prefix
+        backend:
+          service:
+            name: web
+            port:
+              number: 80
+`
+
+var testManifests = []string{
+	testDeploymentManifest,
+	testServiceManifest,
+	testConfigMapManifest,
+	testCronJobManifest,
+	testIngressManifest,
+}
+
+func parseTestManifests(t *testing.T) []unstructured.Unstructured {
+	var manifests []unstructured.Unstructured
+	for _, text := range testManifests {
+		u, err := kube.ParseSingleYamlManifest(text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		manifests = append(manifests, u)
+	}
+	return manifests
+}
+
+// roundTrip converts a manifest to a dynamic value and back again.
+func roundTrip(t *testing.T, converter ManifestConverter, u unstructured.Unstructured) unstructured.Unstructured {
+	t.Helper()
+	value, err := UnstructuredToDynamic(u)
+	if err != nil {
+		t.Fatalf("to dynamic: %v", err)
+	}
+	result, err := converter.ToUnstructured(context.Background(), value)
+	if err != nil {
+		t.Fatalf("to unstructured: %v", err)
+	}
+	again, err := UnstructuredToDynamic(result)
+	if err != nil {
+		t.Fatalf("to dynamic again: %v", err)
+	}
+	if !again.Equal(value) {
+		t.Errorf("dynamic values differ after a round trip:\n%s\n%s", value, again)
+	}
+	return result
+}
+
+// normalizeNumbers turns whole floats into int64, because terraform has a single number type
+// and 1.0 and 1 are the same number.
+func normalizeNumbers(v any) any {
+	switch v := v.(type) {
+	case float64:
+		if v == math.Trunc(v) && v >= math.MinInt64 && v < math.MaxInt64 {
+			return int64(v)
+		}
+		return v
+	case map[string]any:
+		m := make(map[string]any, len(v))
+		for key, value := range v {
+			m[key] = normalizeNumbers(value)
+		}
+		return m
+	case []any:
+		l := make([]any, len(v))
+		for i, value := range v {
+			l[i] = normalizeNumbers(value)
+		}
+		return l
+	default:
+		return v
+	}
+}
+
+func TestRoundTripRealManifests(t *testing.T) {
+	for _, u := range parseTestManifests(t) {
+		t.Run(u.GetKind(), func(t *testing.T) {
+			result := roundTrip(t, ManifestConverter{}, u)
+			if !reflect.DeepEqual(normalizeNumbers(u.Object), result.Object) {
+				t.Errorf("expected %#v, got %#v", u.Object, result.Object)
+			}
+		})
+	}
+}
+
+var testStrings = []string{"", "true", "False", "8080", "-1", "1.0", "1e3", "0x10", "NaN", "web", "app.kubernetes.io/name", "ünïcode ✓", " padded "}
+
+func randomString(r *rand.Rand) string {
+	if r.IntN(2) == 0 {
+		return testStrings[r.IntN(len(testStrings))]
+	}
+	runes := make([]rune, r.IntN(12))
+	for i := range runes {
+		runes[i] = rune(' ' + r.IntN(0x2ff))
+	}
+	return string(runes)
+}
+
+func randomInt(r *rand.Rand) int64 {
+	switch r.IntN(4) {
+	case 0:
+		return []int64{0, 1, -1, math.MaxInt64, math.MinInt64, 1 << 53, 1<<53 + 1}[r.IntN(7)]
+	case 1:
+		return r.Int64N(65536)
+	default:
+		return r.Int64() - r.Int64()
+	}
+}
+
+func randomFloat(r *rand.Rand) float64 {
+	switch r.IntN(3) {
+	case 0:
+		return []float64{0.1, -0.5, 1e300, -1e-300, 5e-324, math.MaxFloat64, 1.5e19}[r.IntN(7)]
+	default:
+		return r.NormFloat64() * math.Pow(10, float64(r.IntN(20)-10))
+	}
+}
+
+func randomValue(r *rand.Rand, depth int) any {
+	choices := 6
+	if depth <= 0 {
+		choices = 4
+	}
+	switch r.IntN(choices) {
+	case 0:
+		return randomString(r)
+	case 1:
+		if r.IntN(2) == 0 {
+			return randomInt(r)
+		}
+		return randomFloat(r)
+	case 2:
+		return r.IntN(2) == 0
+	case 3:
+		if r.IntN(3) == 0 {
+			return nil
+		}
+		return randomString(r)
+	case 4:
+		list := make([]any, r.IntN(4))
+		for i := range list {
+			list[i] = randomValue(r, depth-1)
+		}
+		return list
+	default:
+		return randomObject(r, depth-1)
+	}
+}
+
+func randomObject(r *rand.Rand, depth int) map[string]any {
+	m := make(map[string]any)
+	for range r.IntN(5) {
+		key := randomString(r)
+		if key == "" {
+			key = "key"
+		}
+		m[key] = randomValue(r, depth)
+	}
+	return m
+}
+
+// insertRandomValue adds a random value to a random object within v.
+func insertRandomValue(r *rand.Rand, v any) {
+	switch v := v.(type) {
+	case map[string]any:
+		for _, child := range v {
+			if r.IntN(3) == 0 {
+				insertRandomValue(r, child)
+				return
+			}
+		}
+		v[fmt.Sprintf("x-%d", r.IntN(1000))] = randomValue(r, 3)
+	case []any:
+		if len(v) > 0 {
+			insertRandomValue(r, v[r.IntN(len(v))])
+		}
+	}
+}
+
+func TestRoundTripProperty(t *testing.T) {
+	r := rand.New(rand.NewPCG(48, 49))
+	// random values are put in fields with a known type, so only check the conversion itself
+	untyped := kube.FieldTypeResolverFunc(func(gvk runtimeschema.GroupVersionKind, path []string) kube.FieldType {
+		return kube.FieldTypeUnknown
+	})
+	converter := ManifestConverter{FieldTypes: untyped, NoGuess: true}
+	manifests := parseTestManifests(t)
+	for i := range 500 {
+		var u unstructured.Unstructured
+		if i%2 == 0 {
+			u.Object = randomObject(r, 4)
+		} else {
+			u = *manifests[r.IntN(len(manifests))].DeepCopy()
+			for range 1 + r.IntN(4) {
+				insertRandomValue(r, u.Object)
+			}
+		}
+		result := roundTrip(t, converter, u)
+		if !reflect.DeepEqual(normalizeNumbers(u.Object), result.Object) {
+			t.Fatalf("iteration %d: expected %#v, got %#v", i, u.Object, result.Object)
+		}
+	}
+}
+
+func TestToUnstructuredUnknown(t *testing.T) {
+	ctx := context.Background()
+	metadataType := map[string]attr.Type{"name": types.StringType, "namespace": types.StringType}
+	manifestType := map[string]attr.Type{
+		"apiVersion": types.StringType,
+		"kind":       types.StringType,
+		"metadata":   types.ObjectType{AttrTypes: metadataType},
+		"data":       types.MapType{ElemType: types.StringType},
+	}
+	value := types.ObjectValueMust(manifestType, map[string]attr.Value{
+		"apiVersion": types.StringValue("v1"),
+		"kind":       types.StringValue("Secret"),
+		"metadata": types.ObjectValueMust(metadataType, map[string]attr.Value{
+			"name":      types.StringValue("s"),
+			"namespace": types.StringUnknown(),
+		}),
+		"data": types.MapValueMust(types.StringType, map[string]attr.Value{
+			"known":   types.StringValue("YQ=="),
+			"unknown": types.StringUnknown(),
+		}),
+	})
+
+	_, err := DynamicValueToUnstructured(ctx, types.DynamicValue(value))
+	if err == nil {
+		t.Errorf("expected an error for unknown values")
+	}
+
+	u, err := ManifestConverter{AllowUnknown: true}.ToUnstructured(ctx, types.DynamicValue(value))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsUnknown(u.Object["data"].(map[string]any)["unknown"]) {
+		t.Errorf("expected the unknown value to be kept, got %#v", u.Object["data"])
+	}
+	unknown := UnknownIdentityFields(u.Object)
+	if !reflect.DeepEqual(unknown, []string{"metadata.namespace"}) {
+		t.Errorf("expected metadata.namespace to be unknown, got %v", unknown)
+	}
+
+	unknownMetadata := map[string]any{"apiVersion": "v1", "kind": "Secret", "metadata": Unknown{}}
+	unknown = UnknownIdentityFields(unknownMetadata)
+	if !reflect.DeepEqual(unknown, []string{"metadata.name", "metadata.namespace"}) {
+		t.Errorf("expected the fields of an unknown metadata to be unknown, got %v", unknown)
+	}
+	if missing := MissingIdentityFields(unknownMetadata); len(missing) != 0 {
+		t.Errorf("expected unknown fields not to be missing, got %v", missing)
+	}
+	missing := MissingIdentityFields(map[string]any{"kind": "Secret", "metadata": map[string]any{"name": ""}})
+	if !reflect.DeepEqual(missing, []string{"apiVersion", "metadata.name"}) {
+		t.Errorf("expected apiVersion and metadata.name to be missing, got %v", missing)
+	}
+}
+
+func TestGenerateDocumentListManifests(t *testing.T) {
+	ctx := context.Background()
+	filename := filepath.Join(t.TempDir(), "a.yaml")
+	content := "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: a\n  namespace: ns\ndata:\n  a: b\n" +
+		"---\napiVersion: v1\nkind: Secret\nmetadata:\n  name: a\n  namespace: ns\ntype: Opaque\n"
+	err := os.WriteFile(filename, []byte(content), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fsds := kube.FileSetDefs{{GlobPaths: []string{filename}, SplitYamlDocs: true}}
+	documents, diags := GenerateDocumentList(fsds, DocumentListOptions{})
+	if diags.HasError() {
+		t.Fatal(diags)
+	}
+	object, ok := documents.UnderlyingValue().(types.Object)
+	if !ok {
+		t.Fatalf("expected an object, got %T", documents.UnderlyingValue())
+	}
+	keys := make([]string, 0)
+	for key := range object.Attributes() {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	if !reflect.DeepEqual(keys, []string{"ConfigMap:ns:a", "Secret:ns:a"}) {
+		t.Fatalf("expected keys in the default format, got %v", keys)
+	}
+	document := object.Attributes()["ConfigMap:ns:a"].(types.Object)
+	u, err := DynamicValueToUnstructured(ctx, types.DynamicValue(document.Attributes()["manifest"]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Object["data"].(map[string]any)["a"] != "b" {
+		t.Errorf("expected the manifest of the document, got %v", u.Object)
+	}
+	secret := object.Attributes()["Secret:ns:a"].(types.Object)
+	if secret.Attributes()["kind"].(types.String).ValueString() != "Secret" {
+		t.Errorf("expected the attributes of the document, got %v", secret)
+	}
+}
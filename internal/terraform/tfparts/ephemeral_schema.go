@@ -0,0 +1,135 @@
+package tfparts
+
+import (
+	"fmt"
+
+	dschema "github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	eschema "github.com/hashicorp/terraform-plugin-framework/ephemeral/schema"
+)
+
+// DatasourceToEphemeralAttributes converts datasource attributes so that ephemeral resources can
+// share the schema of the equivalent datasource.
+func DatasourceToEphemeralAttributes(attrs map[string]dschema.Attribute) map[string]eschema.Attribute {
+	result := make(map[string]eschema.Attribute, len(attrs))
+	for name, a := range attrs {
+		result[name] = datasourceToEphemeralAttribute(a)
+	}
+	return result
+}
+
+func datasourceToEphemeralAttribute(a dschema.Attribute) eschema.Attribute {
+	switch a := a.(type) {
+	case dschema.StringAttribute:
+		return eschema.StringAttribute{
+			MarkdownDescription: a.MarkdownDescription,
+			Description:         a.Description,
+			Required:            a.Required,
+			Optional:            a.Optional,
+			Computed:            a.Computed,
+			Sensitive:           a.Sensitive,
+			Validators:          a.Validators,
+		}
+	case dschema.BoolAttribute:
+		return eschema.BoolAttribute{
+			MarkdownDescription: a.MarkdownDescription,
+			Description:         a.Description,
+			Required:            a.Required,
+			Optional:            a.Optional,
+			Computed:            a.Computed,
+			Sensitive:           a.Sensitive,
+			Validators:          a.Validators,
+		}
+	case dschema.Int64Attribute:
+		return eschema.Int64Attribute{
+			MarkdownDescription: a.MarkdownDescription,
+			Description:         a.Description,
+			Required:            a.Required,
+			Optional:            a.Optional,
+			Computed:            a.Computed,
+			Sensitive:           a.Sensitive,
+			Validators:          a.Validators,
+		}
+	case dschema.DynamicAttribute:
+		return eschema.DynamicAttribute{
+			MarkdownDescription: a.MarkdownDescription,
+			Description:         a.Description,
+			Required:            a.Required,
+			Optional:            a.Optional,
+			Computed:            a.Computed,
+			Sensitive:           a.Sensitive,
+			Validators:          a.Validators,
+		}
+	case dschema.ListAttribute:
+		return eschema.ListAttribute{
+			ElementType:         a.ElementType,
+			MarkdownDescription: a.MarkdownDescription,
+			Description:         a.Description,
+			Required:            a.Required,
+			Optional:            a.Optional,
+			Computed:            a.Computed,
+			Sensitive:           a.Sensitive,
+			Validators:          a.Validators,
+		}
+	case dschema.MapAttribute:
+		return eschema.MapAttribute{
+			ElementType:         a.ElementType,
+			MarkdownDescription: a.MarkdownDescription,
+			Description:         a.Description,
+			Required:            a.Required,
+			Optional:            a.Optional,
+			Computed:            a.Computed,
+			Sensitive:           a.Sensitive,
+			Validators:          a.Validators,
+		}
+	case dschema.ObjectAttribute:
+		return eschema.ObjectAttribute{
+			AttributeTypes:      a.AttributeTypes,
+			MarkdownDescription: a.MarkdownDescription,
+			Description:         a.Description,
+			Required:            a.Required,
+			Optional:            a.Optional,
+			Computed:            a.Computed,
+			Sensitive:           a.Sensitive,
+			Validators:          a.Validators,
+		}
+	case dschema.SingleNestedAttribute:
+		return eschema.SingleNestedAttribute{
+			Attributes:          DatasourceToEphemeralAttributes(a.Attributes),
+			MarkdownDescription: a.MarkdownDescription,
+			Description:         a.Description,
+			Required:            a.Required,
+			Optional:            a.Optional,
+			Computed:            a.Computed,
+			Sensitive:           a.Sensitive,
+			Validators:          a.Validators,
+		}
+	case dschema.ListNestedAttribute:
+		return eschema.ListNestedAttribute{
+			NestedObject: eschema.NestedAttributeObject{
+				Attributes: DatasourceToEphemeralAttributes(a.NestedObject.Attributes),
+			},
+			MarkdownDescription: a.MarkdownDescription,
+			Description:         a.Description,
+			Required:            a.Required,
+			Optional:            a.Optional,
+			Computed:            a.Computed,
+			Sensitive:           a.Sensitive,
+			Validators:          a.Validators,
+		}
+	case dschema.MapNestedAttribute:
+		return eschema.MapNestedAttribute{
+			NestedObject: eschema.NestedAttributeObject{
+				Attributes: DatasourceToEphemeralAttributes(a.NestedObject.Attributes),
+			},
+			MarkdownDescription: a.MarkdownDescription,
+			Description:         a.Description,
+			Required:            a.Required,
+			Optional:            a.Optional,
+			Computed:            a.Computed,
+			Sensitive:           a.Sensitive,
+			Validators:          a.Validators,
+		}
+	default:
+		panic(fmt.Sprintf("unsupported datasource attribute type %T", a))
+	}
+}
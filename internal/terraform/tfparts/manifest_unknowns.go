@@ -0,0 +1,67 @@
+package tfparts
+
+import (
+	"strings"
+)
+
+// Unknown stands in for a value of a manifest which is not known until apply. ManifestConverter
+// only produces it when AllowUnknown is set.
+type Unknown struct{}
+
+func IsUnknown(v any) bool {
+	_, ok := v.(Unknown)
+	return ok
+}
+
+// identityFields identify the object, so they must be known when planning. Only namespace may be
+// left out.
+var identityFields = []struct {
+	path     []string
+	required bool
+}{
+	{[]string{"apiVersion"}, true},
+	{[]string{"kind"}, true},
+	{[]string{"metadata", "name"}, true},
+	{[]string{"metadata", "namespace"}, false},
+}
+
+// lookupPath returns the value at path, or the first unknown value on the way to it.
+func lookupPath(object map[string]any, path []string) any {
+	var value any = object
+	for _, element := range path {
+		m, ok := value.(map[string]any)
+		if !ok {
+			return value
+		}
+		value = m[element]
+	}
+	return value
+}
+
+// UnknownIdentityFields returns the identity fields of a manifest which are unknown, including
+// those inside an unknown metadata.
+func UnknownIdentityFields(object map[string]any) []string {
+	var unknown []string
+	for _, field := range identityFields {
+		if IsUnknown(lookupPath(object, field.path)) {
+			unknown = append(unknown, strings.Join(field.path, "."))
+		}
+	}
+	return unknown
+}
+
+// MissingIdentityFields returns the required identity fields of a manifest which are not set.
+// Unknown fields are not missing.
+func MissingIdentityFields(object map[string]any) []string {
+	var missing []string
+	for _, field := range identityFields {
+		if !field.required {
+			continue
+		}
+		value := lookupPath(object, field.path)
+		if s, ok := value.(string); value == nil || (ok && s == "") {
+			missing = append(missing, strings.Join(field.path, "."))
+		}
+	}
+	return missing
+}
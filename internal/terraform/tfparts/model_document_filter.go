@@ -0,0 +1,95 @@
+package tfparts
+
+import (
+	"github.com/davidjspooner/terraform-provider-kubernetes/internal/generic/kube"
+	dschema "github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+type DocumentFilterModel struct {
+	Kinds         types.List   `tfsdk:"kinds"`
+	Groups        types.List   `tfsdk:"groups"`
+	Namespaces    types.List   `tfsdk:"namespaces"`
+	Names         types.List   `tfsdk:"names"`
+	LabelSelector types.String `tfsdk:"label_selector"`
+	Files         types.List   `tfsdk:"files"`
+}
+
+func listToStrings(list types.List) []string {
+	var result []string
+	for _, v := range list.Elements() {
+		if v.IsNull() || v.IsUnknown() {
+			continue
+		}
+		result = append(result, v.(types.String).ValueString())
+	}
+	return result
+}
+
+func (m *DocumentFilterModel) Filter() kube.DocumentFilter {
+	return kube.DocumentFilter{
+		Kinds:         listToStrings(m.Kinds),
+		Groups:        listToStrings(m.Groups),
+		Namespaces:    listToStrings(m.Namespaces),
+		Names:         listToStrings(m.Names),
+		LabelSelector: m.LabelSelector.ValueString(),
+		Files:         listToStrings(m.Files),
+	}
+}
+
+type DocumentFilterModelList []DocumentFilterModel
+
+func (l DocumentFilterModelList) Filters() kube.DocumentFilters {
+	var result kube.DocumentFilters
+	for i := range l {
+		result = append(result, l[i].Filter())
+	}
+	return result
+}
+
+func documentFilterAttribute(description string) dschema.ListNestedAttribute {
+	return dschema.ListNestedAttribute{
+		MarkdownDescription: description,
+		Optional:            true,
+		NestedObject: dschema.NestedAttributeObject{
+			Attributes: map[string]dschema.Attribute{
+				"kinds": dschema.ListAttribute{
+					MarkdownDescription: "Kinds to match",
+					ElementType:         types.StringType,
+					Optional:            true,
+				},
+				"groups": dschema.ListAttribute{
+					MarkdownDescription: "API groups to match ( use \"\" for the core group )",
+					ElementType:         types.StringType,
+					Optional:            true,
+				},
+				"namespaces": dschema.ListAttribute{
+					MarkdownDescription: "Namespaces to match ( use \"\" for documents without a namespace )",
+					ElementType:         types.StringType,
+					Optional:            true,
+				},
+				"names": dschema.ListAttribute{
+					MarkdownDescription: "Glob patterns of names to match",
+					ElementType:         types.StringType,
+					Optional:            true,
+				},
+				"label_selector": dschema.StringAttribute{
+					MarkdownDescription: "Label selector to match, for example `app=web,tier in (frontend)`",
+					Optional:            true,
+				},
+				"files": dschema.ListAttribute{
+					MarkdownDescription: "Glob patterns of source files to match. Patterns with a `/` are matched against the path relative to the working directory, the path within the repository for git and `archive.tgz//entry` for archives, the same keys kube_files uses. Patterns without a `/` are matched against the file name",
+					ElementType:         types.StringType,
+					Optional:            true,
+				},
+			},
+		},
+	}
+}
+
+func DocumentFilterDatasourceAttributes() map[string]dschema.Attribute {
+	return map[string]dschema.Attribute{
+		"include": documentFilterAttribute("Only return documents which match one of these rules. Every criteria set in a rule must match"),
+		"exclude": documentFilterAttribute("Skip documents which match one of these rules. Every criteria set in a rule must match"),
+	}
+}
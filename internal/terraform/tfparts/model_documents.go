@@ -0,0 +1,223 @@
+package tfparts
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/davidjspooner/terraform-provider-kubernetes/internal/generic/kube"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+var DocumentElementAttrType = map[string]attr.Type{
+	"text": types.StringType,
+	"source": types.ObjectType{
+		AttrTypes: map[string]attr.Type{
+			"file": types.StringType,
+			"line": types.Int64Type,
+		},
+	},
+	"api_version": types.StringType,
+	"kind":        types.StringType,
+	"wave":        types.Int64Type,
+	"apply_order": types.Int64Type,
+	"metadata": types.ObjectType{
+		AttrTypes: map[string]attr.Type{
+			"name":      types.StringType,
+			"namespace": types.StringType,
+		},
+	},
+}
+
+// DefaultDocumentKeyFormat keys documents by kind, namespace and name. Kinds with the same name
+// in different api groups collide, use {group_kind} in the key format to tell them apart.
+const DefaultDocumentKeyFormat = "{kind}:{namespace}:{name}"
+
+// DocumentKey expands the placeholders {api_version}, {group}, {version}, {kind}, {group_kind},
+// {namespace} and {name} in format.
+func DocumentKey(format string, u unstructured.Unstructured) string {
+	gvk := u.GroupVersionKind()
+	return strings.NewReplacer(
+		"{api_version}", u.GetAPIVersion(),
+		"{group}", gvk.Group,
+		"{version}", gvk.Version,
+		"{kind}", gvk.Kind,
+		"{group_kind}", gvk.GroupKind().String(),
+		"{namespace}", u.GetNamespace(),
+		"{name}", u.GetName(),
+	).Replace(format)
+}
+
+type DocumentListOptions struct {
+	AllowSensitive bool
+	Strict         bool
+	KeyFormat      string
+	Include        kube.DocumentFilters
+	Exclude        kube.DocumentFilters
+	WaveAnnotation string
+}
+
+type documentEntry struct {
+	key      string
+	value    map[string]attr.Value
+	manifest types.Dynamic
+	order    kube.ApplyOrderKey
+}
+
+// GenerateDocumentList returns a dynamic object holding the documents of the file sets by key.
+// Each document has the attributes of DocumentElementAttrType and the parsed manifest, which
+// has its own type, so the documents can not be a map.
+func GenerateDocumentList(fsds kube.FileSetDefs, options DocumentListOptions) (types.Dynamic, diag.Diagnostics) {
+	var entries []*documentEntry
+	seenKeys := make(map[string]bool)
+
+	var result types.Dynamic
+	var diags diag.Diagnostics
+
+	keyFormat := kube.FirstNonNullString(options.KeyFormat, DefaultDocumentKeyFormat)
+	if !strings.Contains(keyFormat, "{name}") {
+		diags.AddError("Invalid key format", fmt.Sprintf("key format %q must contain {name}", keyFormat))
+		return result, diags
+	}
+
+	handle := func(ec *kube.ExpandedContent, filterPath string) error {
+		if ec.Sensitive && !options.AllowSensitive {
+			return fmt.Errorf("%s contains decrypted content which would be stored in state, use the ephemeral resource instead", ec.Filename)
+		}
+		content := string(ec.Content)
+		parse := kube.ParseSingleYamlManifest
+		if options.Strict {
+			parse = kube.ParseStrictYamlManifest
+		}
+		u, err := parse(content)
+		if err != nil {
+			return fmt.Errorf("error parsing manifest %s line %d: %w", ec.Filename, ec.LineNo, err)
+		}
+
+		kind := u.GetKind()
+		if kind == "" {
+			return fmt.Errorf("error parsing manifest %s line %d: kind is empty", ec.Filename, ec.LineNo)
+		}
+		name := u.GetName()
+		if name == "" {
+			return fmt.Errorf("error parsing manifest %s line %d: name is empty", ec.Filename, ec.LineNo)
+		}
+
+		selected, err := kube.IsSelected(options.Include, options.Exclude, u, filterPath)
+		if err != nil {
+			return err
+		}
+		if !selected {
+			return nil
+		}
+
+		namespace := u.GetNamespace()
+
+		value := map[string]attr.Value{
+			"api_version": types.StringValue(u.GetAPIVersion()),
+			"kind":        types.StringValue(kind),
+			"text":        types.StringValue(content),
+		}
+		value["source"], diags = basetypes.NewObjectValue(
+			map[string]attr.Type{
+				"file": types.StringType,
+				"line": types.Int64Type,
+			},
+			map[string]attr.Value{
+				"file": types.StringValue(ec.Filename),
+				"line": types.Int64Value(int64(ec.LineNo)),
+			},
+		)
+		value["metadata"], diags = basetypes.NewObjectValue(
+			map[string]attr.Type{
+				"name":      types.StringType,
+				"namespace": types.StringType,
+			},
+			map[string]attr.Value{
+				"name":      types.StringValue(name),
+				"namespace": types.StringValue(namespace),
+			},
+		)
+		if diags.HasError() {
+			return DiagsToGoError(diags)
+		}
+		wave, err := kube.Wave(u, kube.FirstNonNullString(options.WaveAnnotation, kube.DefaultWaveAnnotation))
+		if err != nil {
+			return fmt.Errorf("%s line %d: %w", ec.Filename, ec.LineNo, err)
+		}
+		manifest, err := UnstructuredToDynamic(u)
+		if err != nil {
+			return fmt.Errorf("error converting manifest %s line %d: %w", ec.Filename, ec.LineNo, err)
+		}
+		key := DocumentKey(keyFormat, u)
+		if seenKeys[key] {
+			return fmt.Errorf("duplicate manifest found for key %q at %s [%d]", key, ec.Filename, ec.LineNo)
+		}
+		seenKeys[key] = true
+		entries = append(entries, &documentEntry{
+			key:      key,
+			value:    value,
+			manifest: manifest,
+			order: kube.ApplyOrderKey{
+				Wave:     wave,
+				Priority: kube.KindPriority(u.GroupVersionKind().GroupKind()),
+			},
+		})
+		return nil
+	}
+	// document filters match the path of the file within its file set
+	newHandler := func(fsd *kube.FileSetDef) kube.ExpandedContentHandlerFunc {
+		return func(ec *kube.ExpandedContent) error {
+			return handle(ec, fsd.FilterPath(ec.Filename))
+		}
+	}
+
+	for _, fsd := range fsds {
+		fsd.Strict = fsd.Strict || options.Strict
+		err := fsd.ExpandContent(newHandler(fsd))
+		if err != nil {
+			diags.AddError("Error expanding content", err.Error())
+			return result, diags
+		}
+	}
+
+	if len(entries) == 0 {
+		details := "No documents found matching any of the provided file paths"
+		if len(options.Include) > 0 || len(options.Exclude) > 0 {
+			details += " and include/exclude rules"
+		}
+		diags.AddError("No documents found", details)
+		return result, diags
+	}
+
+	orderKeys := make([]kube.ApplyOrderKey, len(entries))
+	for i, entry := range entries {
+		orderKeys[i] = entry.order
+	}
+	applyOrder := kube.ApplyOrder(orderKeys)
+	documentTypes := make(map[string]attr.Type)
+	documentValues := make(map[string]attr.Value)
+	for i, entry := range entries {
+		entry.value["wave"] = types.Int64Value(int64(entry.order.Wave))
+		entry.value["apply_order"] = types.Int64Value(int64(applyOrder[i]))
+		entry.value["manifest"] = entry.manifest.UnderlyingValue()
+		attrTypes := make(map[string]attr.Type, len(entry.value))
+		for name, attrType := range DocumentElementAttrType {
+			attrTypes[name] = attrType
+		}
+		attrTypes["manifest"] = entry.value["manifest"].Type(context.Background())
+		documentTypes[entry.key] = types.ObjectType{AttrTypes: attrTypes}
+		documentValues[entry.key], diags = basetypes.NewObjectValue(attrTypes, entry.value)
+		if diags.HasError() {
+			return result, diags
+		}
+	}
+
+	documents, diags := basetypes.NewObjectValue(documentTypes, documentValues)
+	result = basetypes.NewDynamicValue(documents)
+	return result, diags
+}
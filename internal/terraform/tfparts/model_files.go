@@ -1,22 +1,169 @@
 package tfparts
 
 import (
-	"fmt"
+	"context"
 
 	"github.com/davidjspooner/terraform-provider-kubernetes/internal/generic/kube"
-	"github.com/hashicorp/terraform-plugin-framework/attr"
 	dschema "github.com/hashicorp/terraform-plugin-framework/datasource/schema"
 	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
 	rschema "github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
 	"github.com/hashicorp/terraform-plugin-framework/types"
-	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
 )
 
 type FileSetModel struct {
-	Paths        types.List   `tfsdk:"paths"`
-	TemplateType types.String `tfsdk:"template_type"`
-	Variables    types.Map    `tfsdk:"variables"`
+	Paths        types.List      `tfsdk:"paths"`
+	Exclude      types.List      `tfsdk:"exclude"`
+	TemplateType types.String    `tfsdk:"template_type"`
+	Variables    types.Map       `tfsdk:"variables"`
+	Helm         *HelmModel      `tfsdk:"helm"`
+	Sops         *SopsModel      `tfsdk:"sops"`
+	Jsonnet      *JsonnetModel   `tfsdk:"jsonnet"`
+	Cue          *CueModel       `tfsdk:"cue"`
+	Git          *GitModel       `tfsdk:"git"`
+	Transform    *TransformModel `tfsdk:"transform"`
+}
+
+type HelmModel struct {
+	ReleaseName types.String `tfsdk:"release_name"`
+	Namespace   types.String `tfsdk:"namespace"`
+	Values      types.String `tfsdk:"values"`
+	IncludeCRDs types.Bool   `tfsdk:"include_crds"`
+}
+
+func (h *HelmModel) Options() *kube.HelmOptions {
+	if h == nil {
+		return nil
+	}
+	return &kube.HelmOptions{
+		ReleaseName: h.ReleaseName.ValueString(),
+		Namespace:   h.Namespace.ValueString(),
+		Values:      h.Values.ValueString(),
+		IncludeCRDs: h.IncludeCRDs.ValueBool(),
+	}
+}
+
+type SopsModel struct {
+	AgeKeys    types.List   `tfsdk:"age_keys"`
+	AgeKeyFile types.String `tfsdk:"age_key_file"`
+}
+
+func (s *SopsModel) Options() *kube.SopsOptions {
+	if s == nil {
+		return nil
+	}
+	options := &kube.SopsOptions{
+		AgeKeyFile: s.AgeKeyFile.ValueString(),
+	}
+	for _, key := range s.AgeKeys.Elements() {
+		if key.IsNull() || key.IsUnknown() {
+			continue
+		}
+		options.AgeKeys = append(options.AgeKeys, key.(types.String).ValueString())
+	}
+	return options
+}
+
+type JsonnetModel struct {
+	LibraryPaths      types.List `tfsdk:"library_paths"`
+	TopLevelArguments types.Bool `tfsdk:"top_level_arguments"`
+}
+
+func (j *JsonnetModel) Options() *kube.JsonnetOptions {
+	if j == nil {
+		return nil
+	}
+	options := &kube.JsonnetOptions{
+		TopLevelArguments: j.TopLevelArguments.ValueBool(),
+	}
+	for _, path := range j.LibraryPaths.Elements() {
+		if path.IsNull() || path.IsUnknown() {
+			continue
+		}
+		options.LibraryPaths = append(options.LibraryPaths, path.(types.String).ValueString())
+	}
+	return options
+}
+
+type CueModel struct {
+	Expression       types.String `tfsdk:"expression"`
+	Schema           types.String `tfsdk:"schema"`
+	SchemaExpression types.String `tfsdk:"schema_expression"`
+}
+
+func (c *CueModel) Options() *kube.CueOptions {
+	if c == nil {
+		return nil
+	}
+	return &kube.CueOptions{
+		Expression:       c.Expression.ValueString(),
+		Schema:           c.Schema.ValueString(),
+		SchemaExpression: c.SchemaExpression.ValueString(),
+	}
+}
+
+type GitModel struct {
+	Repository types.String `tfsdk:"repository"`
+	Ref        types.String `tfsdk:"ref"`
+}
+
+func (g *GitModel) Options() *kube.GitOptions {
+	if g == nil {
+		return nil
+	}
+	return &kube.GitOptions{
+		Repository: g.Repository.ValueString(),
+		Ref:        g.Ref.ValueString(),
+	}
+}
+
+type ImageOverrideModel struct {
+	Name    types.String `tfsdk:"name"`
+	NewName types.String `tfsdk:"new_name"`
+	NewTag  types.String `tfsdk:"new_tag"`
+	Digest  types.String `tfsdk:"digest"`
+}
+
+type TransformModel struct {
+	Namespace        types.String         `tfsdk:"namespace"`
+	Labels           types.Map            `tfsdk:"labels"`
+	Annotations      types.Map            `tfsdk:"annotations"`
+	IncludeSelectors types.Bool           `tfsdk:"include_selectors"`
+	Images           []ImageOverrideModel `tfsdk:"images"`
+}
+
+func mapToStrings(m types.Map) map[string]string {
+	result := make(map[string]string)
+	for k, v := range m.Elements() {
+		if v.IsNull() || v.IsUnknown() {
+			continue
+		}
+		result[k] = v.(types.String).ValueString()
+	}
+	return result
+}
+
+func (t *TransformModel) Options() *kube.TransformOptions {
+	if t == nil {
+		return nil
+	}
+	options := &kube.TransformOptions{
+		Namespace:        t.Namespace.ValueString(),
+		Labels:           mapToStrings(t.Labels),
+		Annotations:      mapToStrings(t.Annotations),
+		IncludeSelectors: t.IncludeSelectors.ValueBool(),
+	}
+	for _, image := range t.Images {
+		options.Images = append(options.Images, kube.ImageOverride{
+			Name:    image.Name.ValueString(),
+			NewName: image.NewName.ValueString(),
+			NewTag:  image.NewTag.ValueString(),
+			Digest:  image.Digest.ValueString(),
+		})
+	}
+	return options
 }
 
 type FileSetModelList struct {
@@ -38,6 +185,13 @@ func (f *FileSetModelList) GetFileSetDefs() kube.FileSetDefs {
 			}
 			globPaths[i] = path.(types.String).ValueString()
 		}
+		var excludes []string
+		for _, exclude := range fileSet.Exclude.Elements() {
+			if exclude.IsNull() || exclude.IsUnknown() {
+				continue
+			}
+			excludes = append(excludes, exclude.(types.String).ValueString())
+		}
 		variables := make(map[string]any)
 		tfVars := fileSet.Variables.Elements()
 		for i, v := range tfVars {
@@ -49,14 +203,50 @@ func (f *FileSetModelList) GetFileSetDefs() kube.FileSetDefs {
 
 		fileSetDef := &kube.FileSetDef{
 			GlobPaths:    globPaths,
+			Excludes:     excludes,
 			TemplateType: fileSet.TemplateType.ValueString(),
 			Variables:    variables,
+			Helm:         fileSet.Helm.Options(),
+			Sops:         fileSet.Sops.Options(),
+			Jsonnet:      fileSet.Jsonnet.Options(),
+			Cue:          fileSet.Cue.Options(),
+			Git:          fileSet.Git.Options(),
+			Transform:    fileSet.Transform.Options(),
 		}
 		fileSets = append(fileSets, fileSetDef)
 	}
 	return fileSets
 }
 
+// ValidateUnsplitDocuments rejects transform and cue.schema in the file sets of a config which
+// does not split files into manifest documents, since both apply to each document.
+func ValidateUnsplitDocuments(ctx context.Context, config tfsdk.Config) diag.Diagnostics {
+	var fileSets types.List
+	diags := config.GetAttribute(ctx, path.Root("file_sets"), &fileSets)
+	if diags.HasError() || fileSets.IsNull() || fileSets.IsUnknown() {
+		return diags
+	}
+	for i, element := range fileSets.Elements() {
+		fileSet, ok := element.(types.Object)
+		if !ok || fileSet.IsNull() || fileSet.IsUnknown() {
+			continue
+		}
+		fileSetPath := path.Root("file_sets").AtListIndex(i)
+		attrs := fileSet.Attributes()
+		if transform, ok := attrs["transform"]; ok && !transform.IsNull() {
+			diags.AddAttributeError(fileSetPath.AtName("transform"), "Unsupported option", "transform applies to manifest documents, which are not split out of the files here")
+		}
+		cue, ok := attrs["cue"].(types.Object)
+		if !ok || cue.IsNull() || cue.IsUnknown() {
+			continue
+		}
+		if cueSchema, ok := cue.Attributes()["schema"]; ok && !cueSchema.IsNull() {
+			diags.AddAttributeError(fileSetPath.AtName("cue").AtName("schema"), "Unsupported option", "cue.schema validates manifest documents, which are not split out of the files here")
+		}
+	}
+	return diags
+}
+
 func FileSetsResourceAttributes(required bool) map[string]rschema.Attribute {
 	result := map[string]rschema.Attribute{
 		"file_sets": rschema.ListNestedAttribute{
@@ -64,18 +254,156 @@ func FileSetsResourceAttributes(required bool) map[string]rschema.Attribute {
 			NestedObject: rschema.NestedAttributeObject{
 				Attributes: map[string]rschema.Attribute{
 					"paths": schema.ListAttribute{
-						MarkdownDescription: "List of paths to files",
+						MarkdownDescription: "List of paths to files. Paths may contain `**` to match any number of directories. A path which is a directory expands to all the yaml and json files beneath it. Files within a tar, tar.gz or zip archive are selected with `archive.tgz//pattern`",
 						ElementType:         types.StringType,
 						Required:            true,
 					},
+					"exclude": rschema.ListAttribute{
+						MarkdownDescription: "List of patterns of files to skip. Patterns without a `/` are matched against the file name",
+						ElementType:         types.StringType,
+						Optional:            true,
+					},
 					"variables": rschema.MapAttribute{
 						MarkdownDescription: "Map of variables to be used in template expansions. Requires template_type to be set",
 						ElementType:         types.StringType,
 						Optional:            true,
 					},
 					"template_type": rschema.StringAttribute{
-						MarkdownDescription: "Type of template to be used (text or html)",
+						MarkdownDescription: "Type of template to be used (go/text, go/html, kustomize, helm, jsonnet or cue). With kustomize each path is a kustomization directory which is built in-process. With helm each path is a local chart directory or archive which is rendered offline. With jsonnet each path is evaluated and may return an object, an array or a List. With cue each path is a package directory or file which is exported with variables injected as tags",
+						Optional:            true,
+					},
+					"helm": rschema.SingleNestedAttribute{
+						MarkdownDescription: "Options used when template_type is helm. Variables are applied like --set arguments",
+						Optional:            true,
+						Attributes: map[string]rschema.Attribute{
+							"release_name": rschema.StringAttribute{
+								MarkdownDescription: "Release name used when rendering ( default is the chart name )",
+								Optional:            true,
+							},
+							"namespace": rschema.StringAttribute{
+								MarkdownDescription: "Release namespace used when rendering ( default is `default` )",
+								Optional:            true,
+							},
+							"values": rschema.StringAttribute{
+								MarkdownDescription: "Values document in yaml or json format",
+								Optional:            true,
+							},
+							"include_crds": rschema.BoolAttribute{
+								MarkdownDescription: "Include the chart crds in the rendered documents",
+								Optional:            true,
+							},
+						},
+					},
+					"sops": rschema.SingleNestedAttribute{
+						MarkdownDescription: "Decrypt sops encrypted yaml and json files before template expansion. Files without sops metadata are read unchanged. Decrypted content is never stored in state so can only be read by ephemeral resources",
+						Optional:            true,
+						Attributes: map[string]rschema.Attribute{
+							"age_keys": rschema.ListAttribute{
+								MarkdownDescription: "List of age identities ( AGE-SECRET-KEY-... ). If neither this or age_key_file is set the standard sops key lookup is used",
+								ElementType:         types.StringType,
+								Optional:            true,
+								Sensitive:           true,
+							},
+							"age_key_file": rschema.StringAttribute{
+								MarkdownDescription: "Path to a file of age identities",
+								Optional:            true,
+							},
+						},
+					},
+					"jsonnet": rschema.SingleNestedAttribute{
+						MarkdownDescription: "Options used when template_type is jsonnet",
+						Optional:            true,
+						Attributes: map[string]rschema.Attribute{
+							"library_paths": rschema.ListAttribute{
+								MarkdownDescription: "List of library search paths ( the directory of each file is always searched first )",
+								ElementType:         types.StringType,
+								Optional:            true,
+							},
+							"top_level_arguments": rschema.BoolAttribute{
+								MarkdownDescription: "Pass variables as top level arguments rather than external variables",
+								Optional:            true,
+							},
+						},
+					},
+					"cue": rschema.SingleNestedAttribute{
+						MarkdownDescription: "Options for cue packages and schema validation",
+						Optional:            true,
+						Attributes: map[string]rschema.Attribute{
+							"expression": rschema.StringAttribute{
+								MarkdownDescription: "Path within the package of the objects to emit when template_type is cue ( default is the whole package )",
+								Optional:            true,
+							},
+							"schema": rschema.StringAttribute{
+								MarkdownDescription: "Cue file or package directory which every parsed document must unify with. Only supported where files are split into documents",
+								Optional:            true,
+							},
+							"schema_expression": rschema.StringAttribute{
+								MarkdownDescription: "Path within the schema of the definition to unify with ( eg #Manifest )",
+								Optional:            true,
+							},
+						},
+					},
+					"git": rschema.SingleNestedAttribute{
+						MarkdownDescription: "Read the files from a commit, tag or branch of a local git repository rather than the working tree. Paths are relative to the root of the repository",
 						Optional:            true,
+						Attributes: map[string]rschema.Attribute{
+							"repository": rschema.StringAttribute{
+								MarkdownDescription: "Path to the local git repository",
+								Required:            true,
+							},
+							"ref": rschema.StringAttribute{
+								MarkdownDescription: "Commit, tag or branch to read ( default is HEAD )",
+								Optional:            true,
+							},
+						},
+					},
+					"transform": rschema.SingleNestedAttribute{
+						MarkdownDescription: "Changes applied to each document before it is returned. Only supported where files are split into documents",
+						Optional:            true,
+						Attributes: map[string]rschema.Attribute{
+							"namespace": rschema.StringAttribute{
+								MarkdownDescription: "Namespace set on namespaced documents which do not have one. Discovery is used for kinds which are not built-in or defined by an earlier CustomResourceDefinition",
+								Optional:            true,
+							},
+							"labels": rschema.MapAttribute{
+								MarkdownDescription: "Labels added to every document and to pod templates",
+								ElementType:         types.StringType,
+								Optional:            true,
+							},
+							"annotations": rschema.MapAttribute{
+								MarkdownDescription: "Annotations added to every document and to pod templates",
+								ElementType:         types.StringType,
+								Optional:            true,
+							},
+							"include_selectors": rschema.BoolAttribute{
+								MarkdownDescription: "Also add the labels to the selectors of workloads, services and pod disruption budgets ( defaults to false as selectors of existing workloads are immutable )",
+								Optional:            true,
+							},
+							"images": rschema.ListNestedAttribute{
+								MarkdownDescription: "Container image overrides, matched by image name without tag or digest",
+								Optional:            true,
+								NestedObject: rschema.NestedAttributeObject{
+									Attributes: map[string]rschema.Attribute{
+										"name": rschema.StringAttribute{
+											MarkdownDescription: "Image name to match",
+											Required:            true,
+										},
+										"new_name": rschema.StringAttribute{
+											MarkdownDescription: "Replacement image name",
+											Optional:            true,
+										},
+										"new_tag": rschema.StringAttribute{
+											MarkdownDescription: "Replacement tag",
+											Optional:            true,
+										},
+										"digest": rschema.StringAttribute{
+											MarkdownDescription: "Replacement digest, which takes precedence over new_tag",
+											Optional:            true,
+										},
+									},
+								},
+							},
+						},
 					},
 				},
 			},
@@ -93,18 +421,156 @@ func FileSetsDatasourceAttributes(required bool) map[string]dschema.Attribute {
 			NestedObject: dschema.NestedAttributeObject{
 				Attributes: map[string]dschema.Attribute{
 					"paths": dschema.ListAttribute{
-						MarkdownDescription: "List of paths to files",
+						MarkdownDescription: "List of paths to files. Paths may contain `**` to match any number of directories. A path which is a directory expands to all the yaml and json files beneath it. Files within a tar, tar.gz or zip archive are selected with `archive.tgz//pattern`",
 						ElementType:         types.StringType,
 						Required:            true,
 					},
+					"exclude": dschema.ListAttribute{
+						MarkdownDescription: "List of patterns of files to skip. Patterns without a `/` are matched against the file name",
+						ElementType:         types.StringType,
+						Optional:            true,
+					},
 					"variables": dschema.MapAttribute{
 						MarkdownDescription: "Map of variables to be used in template expansions. Requires template_type to be set",
 						ElementType:         types.StringType,
 						Optional:            true,
 					},
 					"template_type": dschema.StringAttribute{
-						MarkdownDescription: "Type of template to be used (text or html)",
+						MarkdownDescription: "Type of template to be used (go/text, go/html, kustomize, helm, jsonnet or cue). With kustomize each path is a kustomization directory which is built in-process. With helm each path is a local chart directory or archive which is rendered offline. With jsonnet each path is evaluated and may return an object, an array or a List. With cue each path is a package directory or file which is exported with variables injected as tags",
+						Optional:            true,
+					},
+					"helm": dschema.SingleNestedAttribute{
+						MarkdownDescription: "Options used when template_type is helm. Variables are applied like --set arguments",
+						Optional:            true,
+						Attributes: map[string]dschema.Attribute{
+							"release_name": dschema.StringAttribute{
+								MarkdownDescription: "Release name used when rendering ( default is the chart name )",
+								Optional:            true,
+							},
+							"namespace": dschema.StringAttribute{
+								MarkdownDescription: "Release namespace used when rendering ( default is `default` )",
+								Optional:            true,
+							},
+							"values": dschema.StringAttribute{
+								MarkdownDescription: "Values document in yaml or json format",
+								Optional:            true,
+							},
+							"include_crds": dschema.BoolAttribute{
+								MarkdownDescription: "Include the chart crds in the rendered documents",
+								Optional:            true,
+							},
+						},
+					},
+					"sops": dschema.SingleNestedAttribute{
+						MarkdownDescription: "Decrypt sops encrypted yaml and json files before template expansion. Files without sops metadata are read unchanged. Decrypted content is never stored in state so can only be read by ephemeral resources",
+						Optional:            true,
+						Attributes: map[string]dschema.Attribute{
+							"age_keys": dschema.ListAttribute{
+								MarkdownDescription: "List of age identities ( AGE-SECRET-KEY-... ). If neither this or age_key_file is set the standard sops key lookup is used",
+								ElementType:         types.StringType,
+								Optional:            true,
+								Sensitive:           true,
+							},
+							"age_key_file": dschema.StringAttribute{
+								MarkdownDescription: "Path to a file of age identities",
+								Optional:            true,
+							},
+						},
+					},
+					"jsonnet": dschema.SingleNestedAttribute{
+						MarkdownDescription: "Options used when template_type is jsonnet",
+						Optional:            true,
+						Attributes: map[string]dschema.Attribute{
+							"library_paths": dschema.ListAttribute{
+								MarkdownDescription: "List of library search paths ( the directory of each file is always searched first )",
+								ElementType:         types.StringType,
+								Optional:            true,
+							},
+							"top_level_arguments": dschema.BoolAttribute{
+								MarkdownDescription: "Pass variables as top level arguments rather than external variables",
+								Optional:            true,
+							},
+						},
+					},
+					"cue": dschema.SingleNestedAttribute{
+						MarkdownDescription: "Options for cue packages and schema validation",
+						Optional:            true,
+						Attributes: map[string]dschema.Attribute{
+							"expression": dschema.StringAttribute{
+								MarkdownDescription: "Path within the package of the objects to emit when template_type is cue ( default is the whole package )",
+								Optional:            true,
+							},
+							"schema": dschema.StringAttribute{
+								MarkdownDescription: "Cue file or package directory which every parsed document must unify with. Only supported where files are split into documents",
+								Optional:            true,
+							},
+							"schema_expression": dschema.StringAttribute{
+								MarkdownDescription: "Path within the schema of the definition to unify with ( eg #Manifest )",
+								Optional:            true,
+							},
+						},
+					},
+					"git": dschema.SingleNestedAttribute{
+						MarkdownDescription: "Read the files from a commit, tag or branch of a local git repository rather than the working tree. Paths are relative to the root of the repository",
 						Optional:            true,
+						Attributes: map[string]dschema.Attribute{
+							"repository": dschema.StringAttribute{
+								MarkdownDescription: "Path to the local git repository",
+								Required:            true,
+							},
+							"ref": dschema.StringAttribute{
+								MarkdownDescription: "Commit, tag or branch to read ( default is HEAD )",
+								Optional:            true,
+							},
+						},
+					},
+					"transform": dschema.SingleNestedAttribute{
+						MarkdownDescription: "Changes applied to each document before it is returned. Only supported where files are split into documents",
+						Optional:            true,
+						Attributes: map[string]dschema.Attribute{
+							"namespace": dschema.StringAttribute{
+								MarkdownDescription: "Namespace set on namespaced documents which do not have one. Discovery is used for kinds which are not built-in or defined by an earlier CustomResourceDefinition",
+								Optional:            true,
+							},
+							"labels": dschema.MapAttribute{
+								MarkdownDescription: "Labels added to every document and to pod templates",
+								ElementType:         types.StringType,
+								Optional:            true,
+							},
+							"annotations": dschema.MapAttribute{
+								MarkdownDescription: "Annotations added to every document and to pod templates",
+								ElementType:         types.StringType,
+								Optional:            true,
+							},
+							"include_selectors": dschema.BoolAttribute{
+								MarkdownDescription: "Also add the labels to the selectors of workloads, services and pod disruption budgets ( defaults to false as selectors of existing workloads are immutable )",
+								Optional:            true,
+							},
+							"images": dschema.ListNestedAttribute{
+								MarkdownDescription: "Container image overrides, matched by image name without tag or digest",
+								Optional:            true,
+								NestedObject: dschema.NestedAttributeObject{
+									Attributes: map[string]dschema.Attribute{
+										"name": dschema.StringAttribute{
+											MarkdownDescription: "Image name to match",
+											Required:            true,
+										},
+										"new_name": dschema.StringAttribute{
+											MarkdownDescription: "Replacement image name",
+											Optional:            true,
+										},
+										"new_tag": dschema.StringAttribute{
+											MarkdownDescription: "Replacement tag",
+											Optional:            true,
+										},
+										"digest": dschema.StringAttribute{
+											MarkdownDescription: "Replacement digest, which takes precedence over new_tag",
+											Optional:            true,
+										},
+									},
+								},
+							},
+						},
 					},
 				},
 			},
@@ -114,104 +580,3 @@ func FileSetsDatasourceAttributes(required bool) map[string]dschema.Attribute {
 	}
 	return result
 }
-
-var DocumentElementAttrType = map[string]attr.Type{
-	"text": types.StringType,
-	"source": types.ObjectType{
-		AttrTypes: map[string]attr.Type{
-			"file": types.StringType,
-			"line": types.Int64Type,
-		},
-	},
-	"api_version": types.StringType,
-	"kind":        types.StringType,
-	"metadata": types.ObjectType{
-		AttrTypes: map[string]attr.Type{
-			"name":      types.StringType,
-			"namespace": types.StringType,
-		},
-	},
-}
-
-func GenerateDocumentList(fsds kube.FileSetDefs) (types.Map, diag.Diagnostics) {
-	parsedDocsMap := make(map[string]attr.Value)
-
-	var result types.Map
-	var diags diag.Diagnostics
-
-	var handler kube.ExpandedContentHandlerFunc = func(ec *kube.ExpandedContent) error {
-		content := string(ec.Content)
-		u, err := kube.ParseSingleYamlManifest(content)
-		if err != nil {
-			return fmt.Errorf("error parsing manifest: %s", err)
-		}
-
-		kind := u.GetKind()
-		if kind == "" {
-			return fmt.Errorf("error parsing manifest: kind is empty")
-		}
-		name := u.GetName()
-		if name == "" {
-			return fmt.Errorf("error parsing manifest: name is empty")
-		}
-
-		namespace := u.GetNamespace()
-
-		value := map[string]attr.Value{
-			"api_version": types.StringValue(u.GetAPIVersion()),
-			"kind":        types.StringValue(kind),
-			"text":        types.StringValue(content),
-		}
-		value["source"], diags = basetypes.NewObjectValue(
-			map[string]attr.Type{
-				"file": types.StringType,
-				"line": types.Int64Type,
-			},
-			map[string]attr.Value{
-				"file": types.StringValue(ec.Filename),
-				"line": types.Int64Value(int64(ec.LineNo)),
-			},
-		)
-		value["metadata"], diags = basetypes.NewObjectValue(
-			map[string]attr.Type{
-				"name":      types.StringType,
-				"namespace": types.StringType,
-			},
-			map[string]attr.Value{
-				"name":      types.StringValue(name),
-				"namespace": types.StringValue(namespace),
-			},
-		)
-		object, diags := basetypes.NewObjectValue(DocumentElementAttrType, value)
-		if diags.HasError() {
-			return DiagsToGoError(diags)
-		}
-		key := fmt.Sprintf("%s:%s:%s", kind, namespace, name)
-		_, exists := parsedDocsMap[key]
-		if exists {
-			return fmt.Errorf("duplicate manifest found for kind=%q namespace=%q name=%q at %s [%d]", kind, namespace, name, ec.Filename, ec.LineNo)
-		}
-		parsedDocsMap[key] = object
-		return nil
-	}
-
-	err := fsds.ExpandContent(handler)
-	if err != nil {
-		diags.AddError("Error expanding content", err.Error())
-		return result, diags
-	}
-
-	if len(parsedDocsMap) == 0 {
-		details := "No documents found matching any of the provided file paths"
-		diags.AddError("No documents found", details)
-		return result, diags
-	}
-
-	result, diags = basetypes.NewMapValue(
-		types.ObjectType{
-			AttrTypes: DocumentElementAttrType,
-		},
-		parsedDocsMap,
-	)
-	return result, diags
-}
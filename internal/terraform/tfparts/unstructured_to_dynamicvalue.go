@@ -1,8 +1,11 @@
 package tfparts
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
+	"math/big"
 	"reflect"
 	"strings"
 
@@ -13,6 +16,8 @@ import (
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
+// UnstructuredToDynamic converts a manifest to a dynamic value. Numbers become terraform numbers
+// without losing precision, lists become tuples, maps become objects and nulls stay null.
 func UnstructuredToDynamic(u unstructured.Unstructured) (basetypes.DynamicValue, error) {
 	// Convert the unstructured object to a map[string]interface{}
 	var dynamicValue basetypes.DynamicValue
@@ -39,16 +44,31 @@ func DiagsToGoError(diags diag.Diagnostics) error {
 	return nil
 }
 
+func floatToAttrValue(f float64) (attr.Type, attr.Value, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil, nil, fmt.Errorf("unsupported number %v", f)
+	}
+	return types.NumberType, basetypes.NewNumberValue(big.NewFloat(f)), nil
+}
+
 func anyToAttrValue(v interface{}) (attr.Type, attr.Value, error) {
 	switch v := v.(type) {
 	case string:
 		return types.StringType, basetypes.NewStringValue(v), nil
 	case int64:
-		return types.Int64Type, basetypes.NewInt64Value(v), nil
+		return types.NumberType, basetypes.NewNumberValue(new(big.Float).SetInt64(v)), nil
 	case float64:
-		return types.Float64Type, basetypes.NewFloat64Value(v), nil
+		return floatToAttrValue(v)
+	case json.Number:
+		bf, _, err := big.ParseFloat(v.String(), 10, 512, big.ToNearestEven)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid number %q: %w", v, err)
+		}
+		return types.NumberType, basetypes.NewNumberValue(bf), nil
 	case bool:
 		return types.BoolType, basetypes.NewBoolValue(v), nil
+	case nil:
+		return types.DynamicType, basetypes.NewDynamicNull(), nil
 	default:
 		rValue := reflect.ValueOf(v)
 		for rValue.Kind() == reflect.Ptr || rValue.Kind() == reflect.Interface {
@@ -59,6 +79,16 @@ func anyToAttrValue(v interface{}) (attr.Type, attr.Value, error) {
 			}
 		}
 		switch rValue.Kind() {
+		case reflect.String:
+			return types.StringType, basetypes.NewStringValue(rValue.String()), nil
+		case reflect.Bool:
+			return types.BoolType, basetypes.NewBoolValue(rValue.Bool()), nil
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return types.NumberType, basetypes.NewNumberValue(new(big.Float).SetInt64(rValue.Int())), nil
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return types.NumberType, basetypes.NewNumberValue(new(big.Float).SetUint64(rValue.Uint())), nil
+		case reflect.Float32, reflect.Float64:
+			return floatToAttrValue(rValue.Float())
 		case reflect.Map:
 			m := make(map[string]attr.Value)
 			t := make(map[string]attr.Type)
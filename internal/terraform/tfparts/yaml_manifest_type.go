@@ -0,0 +1,109 @@
+package tfparts
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/davidjspooner/terraform-provider-kubernetes/internal/generic/kube"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/attr/xattr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+var _ basetypes.StringTypable = YamlManifestType{}
+var _ basetypes.StringValuableWithSemanticEquals = YamlManifestValue{}
+var _ xattr.ValidateableAttribute = YamlManifestValue{}
+
+// YamlManifestType is a string holding a single yaml or json manifest. Values which parse to the
+// same object are semantically equal, so formatting and key order are ignored.
+type YamlManifestType struct {
+	basetypes.StringType
+}
+
+func (t YamlManifestType) Equal(o attr.Type) bool {
+	other, ok := o.(YamlManifestType)
+	if !ok {
+		return false
+	}
+	return t.StringType.Equal(other.StringType)
+}
+
+func (t YamlManifestType) String() string {
+	return "YamlManifestType"
+}
+
+func (t YamlManifestType) ValueFromString(ctx context.Context, in basetypes.StringValue) (basetypes.StringValuable, diag.Diagnostics) {
+	return YamlManifestValue{StringValue: in}, nil
+}
+
+func (t YamlManifestType) ValueFromTerraform(ctx context.Context, in tftypes.Value) (attr.Value, error) {
+	attrValue, err := t.StringType.ValueFromTerraform(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	stringValue, ok := attrValue.(basetypes.StringValue)
+	if !ok {
+		return nil, fmt.Errorf("unexpected value type of %T", attrValue)
+	}
+	return YamlManifestValue{StringValue: stringValue}, nil
+}
+
+func (t YamlManifestType) ValueType(ctx context.Context) attr.Value {
+	return YamlManifestValue{}
+}
+
+type YamlManifestValue struct {
+	basetypes.StringValue
+}
+
+func (v YamlManifestValue) Equal(o attr.Value) bool {
+	other, ok := o.(YamlManifestValue)
+	if !ok {
+		return false
+	}
+	return v.StringValue.Equal(other.StringValue)
+}
+
+func (v YamlManifestValue) Type(ctx context.Context) attr.Type {
+	return YamlManifestType{}
+}
+
+func (v YamlManifestValue) StringSemanticEquals(ctx context.Context, newValuable basetypes.StringValuable) (bool, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	newValue, ok := newValuable.(YamlManifestValue)
+	if !ok {
+		diags.AddError("Semantic Equality Check Error", fmt.Sprintf("unexpected value type of %T", newValuable))
+		return false, diags
+	}
+	oldManifest, err := kube.ParseSingleYamlManifest(v.ValueString())
+	if err != nil {
+		return false, nil
+	}
+	newManifest, err := kube.ParseSingleYamlManifest(newValue.ValueString())
+	if err != nil {
+		return false, nil
+	}
+	return reflect.DeepEqual(oldManifest.Object, newManifest.Object), nil
+}
+
+func (v YamlManifestValue) ValidateAttribute(ctx context.Context, req xattr.ValidateAttributeRequest, resp *xattr.ValidateAttributeResponse) {
+	if v.IsNull() || v.IsUnknown() {
+		return
+	}
+	_, err := kube.ParseSingleYamlManifest(v.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(req.Path, "Invalid manifest", err.Error())
+	}
+}
+
+// Manifest parses the value. A null or unknown value gives an empty object.
+func (v YamlManifestValue) Manifest() (unstructured.Unstructured, error) {
+	if v.IsNull() || v.IsUnknown() {
+		return unstructured.Unstructured{}, nil
+	}
+	return kube.ParseSingleYamlManifest(v.ValueString())
+}
@@ -2,8 +2,10 @@ package tfprovider
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
-	"path/filepath"
+	"unicode/utf8"
 
 	"github.com/davidjspooner/terraform-provider-kubernetes/internal/generic/kube"
 	"github.com/davidjspooner/terraform-provider-kubernetes/internal/terraform/tfparts"
@@ -18,6 +20,7 @@ import (
 
 // Ensure provider defined types fully satisfy framework interfaces.
 var _ datasource.DataSource = &DataSourceKubeFiles{}
+var _ datasource.DataSourceWithValidateConfig = &DataSourceKubeFiles{}
 
 func init() {
 	// Register the data source with the provider.
@@ -37,20 +40,51 @@ type DataSourceKubeFiles struct {
 // FileManifestsModel describes the resource data tfshared.
 type FileModel struct {
 	tfparts.FileSetModelList
-	Contents types.Map `tfsdk:"contents"`
+	Root           types.String `tfsdk:"root"`
+	Contents       types.Map    `tfsdk:"contents"`
+	BinaryContents types.Map    `tfsdk:"binary_contents"`
+	Files          types.Map    `tfsdk:"files"`
+}
+
+var fileInfoAttrType = map[string]attr.Type{
+	"size":   types.Int64Type,
+	"mode":   types.StringType,
+	"sha256": types.StringType,
 }
 
 func (r *DataSourceKubeFiles) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
 	resp.TypeName = req.ProviderTypeName + r.tfTypeNameSuffix
 }
 
+// ValidateConfig rejects the file set options which apply to manifest documents, since files are
+// read whole.
+func (r *DataSourceKubeFiles) ValidateConfig(ctx context.Context, req datasource.ValidateConfigRequest, resp *datasource.ValidateConfigResponse) {
+	resp.Diagnostics.Append(tfparts.ValidateUnsplitDocuments(ctx, req.Config)...)
+}
+
 func (r *DataSourceKubeFiles) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
 	attr := map[string]schema.Attribute{
+		"root": schema.StringAttribute{
+			MarkdownDescription: "Directory which keys are relative to. Files read from git are keyed by their path within the repository ( defaults to the current directory )",
+			Optional:            true,
+		},
 		"contents": schema.MapAttribute{
-			MarkdownDescription: "results", //TODO
+			MarkdownDescription: "Contents of the utf-8 files keyed by relative path",
 			ElementType:         types.StringType,
 			Computed:            true,
 		},
+		"binary_contents": schema.MapAttribute{
+			MarkdownDescription: "Base64 encoded contents of the files which are not utf-8 keyed by relative path",
+			ElementType:         types.StringType,
+			Computed:            true,
+		},
+		"files": schema.MapAttribute{
+			MarkdownDescription: "The size, octal mode and sha256 of every file keyed by relative path",
+			ElementType: types.ObjectType{
+				AttrTypes: fileInfoAttrType,
+			},
+			Computed: true,
+		},
 	}
 	resp.Schema = schema.Schema{
 		// This description is used by the documentation generator and the language server.
@@ -74,30 +108,70 @@ func (r *DataSourceKubeFiles) Read(ctx context.Context, req datasource.ReadReque
 	}
 
 	fsds := config.GetFileSetDefs()
-	contents := make(map[string]attr.Value)
+	root := config.Root.ValueString()
+	sm := kube.StringMap{PathKeys: true}
+	files := make(map[string]attr.Value)
 
-	var handler kube.ExpandedContentHandlerFunc = func(content *kube.ExpandedContent) error {
-		basename := filepath.Base(content.Filename)
-		_, exists := contents[basename]
-		if exists {
-			return fmt.Errorf("duplicate name %s", basename)
+	for _, fsd := range fsds {
+		var handler kube.ExpandedContentHandlerFunc = func(content *kube.ExpandedContent) error {
+			if content.Sensitive {
+				return fmt.Errorf("%s contains decrypted content which would be stored in state", content.Filename)
+			}
+			key, err := fsd.RelativePath(root, content.Filename)
+			if err != nil {
+				return err
+			}
+			if utf8.Valid(content.Content) {
+				err = sm.AddText(key, string(content.Content))
+			} else {
+				err = sm.EncodeAsBase64AndAdd(key, content.Content)
+			}
+			if err != nil {
+				return fmt.Errorf("error adding %s: %w", content.Filename, err)
+			}
+			hash := sha256.Sum256(content.Content)
+			info, diags := basetypes.NewObjectValue(fileInfoAttrType, map[string]attr.Value{
+				"size":   types.Int64Value(int64(len(content.Content))),
+				"mode":   types.StringValue(fmt.Sprintf("%04o", content.Mode.Perm())),
+				"sha256": types.StringValue(hex.EncodeToString(hash[:])),
+			})
+			if diags.HasError() {
+				return fmt.Errorf("error describing %s: %v", content.Filename, diags)
+			}
+			files[key] = info
+			return nil
+		}
+		err := fsd.ExpandContent(handler)
+		if err != nil {
+			resp.Diagnostics.AddError(
+				"Error expanding file set",
+				fmt.Sprintf("Error expanding file set: %s", err),
+			)
+			return
 		}
-		contents[basename] = types.StringValue(string(content.Content))
-		return nil
-	}
-	err := fsds.ExpandContent(handler)
-	if err != nil {
-		resp.Diagnostics.AddError(
-			"Error expanding file set",
-			fmt.Sprintf("Error expanding file set: %s", err),
-		)
-		return
 	}
+
+	contents := make(map[string]attr.Value)
+	binaryContents := make(map[string]attr.Value)
+	_ = sm.ForEachTextContent(func(key, value string) error {
+		contents[key] = types.StringValue(value)
+		return nil
+	})
+	_ = sm.ForEachBase64Content(func(key, value string) error {
+		binaryContents[key] = types.StringValue(value)
+		return nil
+	})
+
 	var diags diag.Diagnostics
 	config.Contents, diags = basetypes.NewMapValue(types.StringType, contents)
-
-	resp.Diagnostics.Append(resp.State.Set(ctx, &config)...)
-	if diags.HasError() {
+	resp.Diagnostics.Append(diags...)
+	config.BinaryContents, diags = basetypes.NewMapValue(types.StringType, binaryContents)
+	resp.Diagnostics.Append(diags...)
+	config.Files, diags = basetypes.NewMapValue(types.ObjectType{AttrTypes: fileInfoAttrType}, files)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
 		return
 	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &config)...)
 }
@@ -3,16 +3,16 @@ package tfprovider
 import (
 	"context"
 
+	"github.com/davidjspooner/terraform-provider-kubernetes/internal/generic/kube"
 	"github.com/davidjspooner/terraform-provider-kubernetes/internal/terraform/tfparts"
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
 	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
-	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
 )
 
 // Ensure provider defined types fully satisfy framework interfaces.
-var _ datasource.DataSource = &DataSourceKubeManifestFiles{}
+var _ datasource.DataSourceWithConfigure = &DataSourceKubeManifestFiles{}
 
 func init() {
 	// Register the data source with the provider.
@@ -26,13 +26,19 @@ func init() {
 
 // DataSourceKubeManifestFiles defines the resource implementation.
 type DataSourceKubeManifestFiles struct {
+	provider         *KubeProvider
 	tfTypeNameSuffix string
 }
 
 // FileManifestsModel describes the resource data tfshared.
 type FileManifestsModel struct {
 	tfparts.FileSetModelList
-	Documents types.Map `tfsdk:"documents"`
+	Documents      types.Dynamic                   `tfsdk:"documents"`
+	Strict         types.Bool                      `tfsdk:"strict"`
+	KeyFormat      types.String                    `tfsdk:"key_format"`
+	WaveAnnotation types.String                    `tfsdk:"wave_annotation"`
+	Include        tfparts.DocumentFilterModelList `tfsdk:"include"`
+	Exclude        tfparts.DocumentFilterModelList `tfsdk:"exclude"`
 }
 
 func (r *DataSourceKubeManifestFiles) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
@@ -48,6 +54,18 @@ func (r *DataSourceKubeManifestFiles) Schema(ctx context.Context, req datasource
 			},
 			Computed: true,
 		},
+		"strict": schema.BoolAttribute{
+			MarkdownDescription: "Reject documents with duplicate keys, unknown metadata fields or unknown top level fields for built-in kinds. Top level fields of custom resources and of kinds not built into the provider are not checked ( defaults to false )",
+			Optional:            true,
+		},
+		"key_format": schema.StringAttribute{
+			MarkdownDescription: "Format of the keys of documents. Placeholders are {api_version}, {group}, {version}, {kind}, {group_kind}, {namespace} and {name} ( defaults to " + tfparts.DefaultDocumentKeyFormat + " )",
+			Optional:            true,
+		},
+		"wave_annotation": schema.StringAttribute{
+			MarkdownDescription: "Annotation holding the integer wave of a document. Documents are given an apply_order tier by wave and then by kind ( defaults to " + kube.DefaultWaveAnnotation + " )",
+			Optional:            true,
+		},
 	}
 	resp.Schema = schema.Schema{
 		// This description is used by the documentation generator and the language server.
@@ -56,11 +74,21 @@ func (r *DataSourceKubeManifestFiles) Schema(ctx context.Context, req datasource
 		Attributes: MergeDataAttributes(
 			attr,
 			tfparts.FileSetsDatasourceAttributes(true),
+			tfparts.DocumentFilterDatasourceAttributes(),
 		),
 	}
 }
 
-func (r *DataSourceKubeManifestFiles) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+func (r *DataSourceKubeManifestFiles) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	provider, ok := req.ProviderData.(*KubeProvider)
+	if !ok {
+		resp.Diagnostics.AddError("Unexpected Type", "Expected provider data to be of type *KubeProvider")
+		return
+	}
+	r.provider = provider
 }
 
 func (r *DataSourceKubeManifestFiles) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
@@ -74,8 +102,16 @@ func (r *DataSourceKubeManifestFiles) Read(ctx context.Context, req datasource.R
 	for i := range fsds {
 		fsds[i].SplitYamlDocs = true
 	}
+	enableDiscovery(fsds, r.provider)
 
-	results, diags := tfparts.GenerateDocumentList(fsds)
+	results, diags := tfparts.GenerateDocumentList(fsds, tfparts.DocumentListOptions{
+		AllowSensitive: false,
+		Strict:         config.Strict.ValueBool(),
+		KeyFormat:      config.KeyFormat.ValueString(),
+		Include:        config.Include.Filters(),
+		Exclude:        config.Exclude.Filters(),
+		WaveAnnotation: config.WaveAnnotation.ValueString(),
+	})
 	resp.Diagnostics.Append(diags...)
 	if diags.HasError() {
 		return
@@ -0,0 +1,180 @@
+package tfprovider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/davidjspooner/terraform-provider-kubernetes/internal/generic/kube"
+	"github.com/davidjspooner/terraform-provider-kubernetes/internal/terraform/tfparts"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ datasource.DataSourceWithConfigure = &DataSourceKubeManifestReferences{}
+
+func init() {
+	// Register the data source with the provider.
+	RegisterDataSource(func() datasource.DataSource {
+		ds := &DataSourceKubeManifestReferences{
+			tfTypeNameSuffix: "_manifest_references",
+		}
+		return ds
+	})
+}
+
+// DataSourceKubeManifestReferences reports the references between the documents of file sets.
+type DataSourceKubeManifestReferences struct {
+	provider         *KubeProvider
+	tfTypeNameSuffix string
+}
+
+type ManifestReferencesModel struct {
+	tfparts.FileSetModelList
+	FailOnDangling types.Bool   `tfsdk:"fail_on_dangling"`
+	References     types.List   `tfsdk:"references"`
+	Dangling       types.List   `tfsdk:"dangling"`
+	JSON           types.String `tfsdk:"json"`
+	DOT            types.String `tfsdk:"dot"`
+}
+
+var referenceAttrType = map[string]attr.Type{
+	"from":     types.StringType,
+	"to":       types.StringType,
+	"type":     types.StringType,
+	"optional": types.BoolType,
+	"found":    types.BoolType,
+}
+
+func (r *DataSourceKubeManifestReferences) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + r.tfTypeNameSuffix
+}
+
+func (r *DataSourceKubeManifestReferences) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	attr := map[string]schema.Attribute{
+		"fail_on_dangling": schema.BoolAttribute{
+			MarkdownDescription: "Fail when a document references an object which is in none of the file sets ( defaults to false )",
+			Optional:            true,
+		},
+		"references": schema.ListAttribute{
+			MarkdownDescription: "References from workloads to config maps, secrets, service accounts and claims, from services to workloads, from ingresses to services and from role bindings to roles. Objects use the default document key format",
+			ElementType: types.ObjectType{
+				AttrTypes: referenceAttrType,
+			},
+			Computed: true,
+		},
+		"dangling": schema.ListAttribute{
+			MarkdownDescription: "Descriptions of the references to objects which are missing and not optional",
+			ElementType:         types.StringType,
+			Computed:            true,
+		},
+		"json": schema.StringAttribute{
+			MarkdownDescription: "The reference graph as json",
+			Computed:            true,
+		},
+		"dot": schema.StringAttribute{
+			MarkdownDescription: "The reference graph in graphviz dot format",
+			Computed:            true,
+		},
+	}
+	resp.Schema = schema.Schema{
+		MarkdownDescription: "Analyse the references between the documents of a list of file sets",
+
+		Attributes: MergeDataAttributes(
+			attr,
+			tfparts.FileSetsDatasourceAttributes(true),
+		),
+	}
+}
+
+func (r *DataSourceKubeManifestReferences) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	provider, ok := req.ProviderData.(*KubeProvider)
+	if !ok {
+		resp.Diagnostics.AddError("Unexpected Type", "Expected provider data to be of type *KubeProvider")
+		return
+	}
+	r.provider = provider
+}
+
+func (r *DataSourceKubeManifestReferences) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var config ManifestReferencesModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	fsds := config.GetFileSetDefs()
+	for i := range fsds {
+		fsds[i].SplitYamlDocs = true
+	}
+	enableDiscovery(fsds, r.provider)
+
+	var objects []unstructured.Unstructured
+	var handler kube.ExpandedContentHandlerFunc = func(ec *kube.ExpandedContent) error {
+		u, err := kube.ParseSingleYamlManifest(string(ec.Content))
+		if err != nil {
+			return fmt.Errorf("error parsing manifest %s line %d: %w", ec.Filename, ec.LineNo, err)
+		}
+		objects = append(objects, u)
+		return nil
+	}
+	err := fsds.ExpandContent(handler)
+	if err != nil {
+		resp.Diagnostics.AddError("Error expanding content", err.Error())
+		return
+	}
+
+	graph := kube.AnalyseReferences(objects)
+	var references []attr.Value
+	for _, reference := range graph.References {
+		value, diags := basetypes.NewObjectValue(referenceAttrType, map[string]attr.Value{
+			"from":     types.StringValue(reference.From.String()),
+			"to":       types.StringValue(reference.To.String()),
+			"type":     types.StringValue(reference.Type),
+			"optional": types.BoolValue(reference.Optional),
+			"found":    types.BoolValue(reference.Found),
+		})
+		resp.Diagnostics.Append(diags...)
+		if diags.HasError() {
+			return
+		}
+		references = append(references, value)
+	}
+	var dangling []attr.Value
+	var danglingText []string
+	for _, reference := range graph.Dangling() {
+		dangling = append(dangling, types.StringValue(reference.String()))
+		danglingText = append(danglingText, reference.String())
+	}
+	if config.FailOnDangling.ValueBool() && len(dangling) > 0 {
+		resp.Diagnostics.AddError("Dangling references", strings.Join(danglingText, "\n"))
+		return
+	}
+
+	var diags diag.Diagnostics
+	config.References, diags = basetypes.NewListValue(types.ObjectType{AttrTypes: referenceAttrType}, references)
+	resp.Diagnostics.Append(diags...)
+	config.Dangling, diags = basetypes.NewListValue(types.StringType, dangling)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	graphJSON, err := graph.JSON()
+	if err != nil {
+		resp.Diagnostics.AddError("Error encoding graph", err.Error())
+		return
+	}
+	config.JSON = types.StringValue(graphJSON)
+	config.DOT = types.StringValue(graph.DOT())
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &config)...)
+}
@@ -32,6 +32,7 @@ type DataSourceManifest struct {
 // FileManifestsModel describes the resource data tfshared.
 type ManifestsModel struct {
 	Text     types.String  `tfsdk:"text"`
+	Strict   types.Bool    `tfsdk:"strict"`
 	Manifest types.Dynamic `tfsdk:"manifest"`
 }
 
@@ -49,6 +50,10 @@ func (r *DataSourceManifest) Schema(ctx context.Context, req datasource.SchemaRe
 				MarkdownDescription: "yaml formatted manifest",
 				Required:            true,
 			},
+			"strict": schema.BoolAttribute{
+				MarkdownDescription: "Reject duplicate keys, unknown metadata fields or unknown top level fields for built-in kinds. Top level fields of custom resources and of kinds not built into the provider are not checked ( defaults to false )",
+				Optional:            true,
+			},
 			"manifest": schema.DynamicAttribute{
 				MarkdownDescription: "the manifest as an object",
 				Computed:            true,
@@ -73,7 +78,11 @@ func (r *DataSourceManifest) Read(ctx context.Context, req datasource.ReadReques
 		return
 	}
 
-	u, err := kube.ParseSingleYamlManifest(text)
+	parse := kube.ParseSingleYamlManifest
+	if config.Strict.ValueBool() {
+		parse = kube.ParseStrictYamlManifest
+	}
+	u, err := parse(text)
 	if err != nil {
 		resp.Diagnostics.AddError("Error parsing yaml", err.Error())
 		return
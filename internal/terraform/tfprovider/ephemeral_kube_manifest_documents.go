@@ -0,0 +1,107 @@
+package tfprovider
+
+import (
+	"context"
+
+	"github.com/davidjspooner/terraform-provider-kubernetes/internal/generic/kube"
+	"github.com/davidjspooner/terraform-provider-kubernetes/internal/terraform/tfparts"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
+	eschema "github.com/hashicorp/terraform-plugin-framework/ephemeral/schema"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ ephemeral.EphemeralResourceWithConfigure = &EphemeralKubeManifestFiles{}
+
+func init() {
+	// Register the ephemeral resource with the provider.
+	RegisterEphemeralResource(func() ephemeral.EphemeralResource {
+		return &EphemeralKubeManifestFiles{
+			tfTypeNameSuffix: "_manifest_documents",
+		}
+	})
+}
+
+// EphemeralKubeManifestFiles is the ephemeral equivalent of the kube_manifest_documents data source.
+// As the results are never stored in state it may read sops encrypted files.
+type EphemeralKubeManifestFiles struct {
+	provider         *KubeProvider
+	tfTypeNameSuffix string
+}
+
+func (r *EphemeralKubeManifestFiles) Configure(ctx context.Context, req ephemeral.ConfigureRequest, resp *ephemeral.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	provider, ok := req.ProviderData.(*KubeProvider)
+	if !ok {
+		resp.Diagnostics.AddError("Unexpected Type", "Expected provider data to be of type *KubeProvider")
+		return
+	}
+	r.provider = provider
+}
+
+func (r *EphemeralKubeManifestFiles) Metadata(ctx context.Context, req ephemeral.MetadataRequest, resp *ephemeral.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + r.tfTypeNameSuffix
+}
+
+func (r *EphemeralKubeManifestFiles) Schema(ctx context.Context, req ephemeral.SchemaRequest, resp *ephemeral.SchemaResponse) {
+	attr := map[string]schema.Attribute{
+		"documents": schema.DynamicAttribute{
+			MarkdownDescription: "Object holding the documents found in the file sets by key. Each document has text, source, api_version, kind, wave, apply_order, metadata and the parsed manifest",
+			Computed:            true,
+			Sensitive:           true,
+		},
+		"strict": schema.BoolAttribute{
+			MarkdownDescription: "Reject documents with duplicate keys, unknown metadata fields or unknown top level fields for built-in kinds. Top level fields of custom resources and of kinds not built into the provider are not checked ( defaults to false )",
+			Optional:            true,
+		},
+		"key_format": schema.StringAttribute{
+			MarkdownDescription: "Format of the keys of documents. Placeholders are {api_version}, {group}, {version}, {kind}, {group_kind}, {namespace} and {name} ( defaults to " + tfparts.DefaultDocumentKeyFormat + " )",
+			Optional:            true,
+		},
+		"wave_annotation": schema.StringAttribute{
+			MarkdownDescription: "Annotation holding the integer wave of a document. Documents are given an apply_order tier by wave and then by kind ( defaults to " + kube.DefaultWaveAnnotation + " )",
+			Optional:            true,
+		},
+	}
+	resp.Schema = eschema.Schema{
+		MarkdownDescription: "Read yaml from a list of files and return all the inner documents without storing them in state. Use this to read sops encrypted files",
+
+		Attributes: tfparts.DatasourceToEphemeralAttributes(MergeDataAttributes(
+			attr,
+			tfparts.FileSetsDatasourceAttributes(true),
+			tfparts.DocumentFilterDatasourceAttributes(),
+		)),
+	}
+}
+
+func (r *EphemeralKubeManifestFiles) Open(ctx context.Context, req ephemeral.OpenRequest, resp *ephemeral.OpenResponse) {
+	var config FileManifestsModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	fsds := config.GetFileSetDefs()
+	for i := range fsds {
+		fsds[i].SplitYamlDocs = true
+	}
+	enableDiscovery(fsds, r.provider)
+
+	results, diags := tfparts.GenerateDocumentList(fsds, tfparts.DocumentListOptions{
+		AllowSensitive: true,
+		Strict:         config.Strict.ValueBool(),
+		KeyFormat:      config.KeyFormat.ValueString(),
+		Include:        config.Include.Filters(),
+		Exclude:        config.Exclude.Filters(),
+		WaveAnnotation: config.WaveAnnotation.ValueString(),
+	})
+	resp.Diagnostics.Append(diags...)
+	if diags.HasError() {
+		return
+	}
+	config.Documents = results
+
+	resp.Diagnostics.Append(resp.Result.Set(ctx, &config)...)
+}
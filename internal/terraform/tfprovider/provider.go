@@ -9,6 +9,7 @@ import (
 	"github.com/davidjspooner/terraform-provider-kubernetes/internal/terraform/tfparts"
 
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
 	"github.com/hashicorp/terraform-plugin-framework/function"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
@@ -19,6 +20,7 @@ import (
 // Ensure ScaffoldingProvider satisfies various provider interfaces.
 var _ provider.Provider = &KubeProvider{}
 var _ provider.ProviderWithFunctions = &KubeProvider{}
+var _ provider.ProviderWithEphemeralResources = &KubeProvider{}
 
 // KubeProvider defines the provider implementation.
 type KubeProvider struct {
@@ -129,6 +131,7 @@ func (p *KubeProvider) Configure(ctx context.Context, req provider.ConfigureRequ
 
 	resp.DataSourceData = p
 	resp.ResourceData = p
+	resp.EphemeralResourceData = p
 }
 
 var lock = sync.Mutex{}
@@ -136,6 +139,7 @@ var lock = sync.Mutex{}
 var supportedResources []func() resource.Resource
 var supportedDataSources []func() datasource.DataSource
 var supportedFunctions []func() function.Function
+var supportedEphemeralResources []func() ephemeral.EphemeralResource
 
 func RegisterResource(r func() resource.Resource) {
 	lock.Lock()
@@ -153,6 +157,12 @@ func RegisterFunction(f func() function.Function) {
 	supportedFunctions = append(supportedFunctions, f)
 }
 
+func RegisterEphemeralResource(e func() ephemeral.EphemeralResource) {
+	lock.Lock()
+	defer lock.Unlock()
+	supportedEphemeralResources = append(supportedEphemeralResources, e)
+}
+
 func (p *KubeProvider) Resources(ctx context.Context) []func() resource.Resource {
 	lock.Lock()
 	defer lock.Unlock()
@@ -171,6 +181,12 @@ func (p *KubeProvider) Functions(ctx context.Context) []func() function.Function
 	return supportedFunctions
 }
 
+func (p *KubeProvider) EphemeralResources(ctx context.Context) []func() ephemeral.EphemeralResource {
+	lock.Lock()
+	defer lock.Unlock()
+	return supportedEphemeralResources
+}
+
 func NewProvider(version string) func() provider.Provider {
 	return func() provider.Provider {
 		return &KubeProvider{
@@ -10,6 +10,7 @@ import (
 	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
 )
@@ -40,6 +41,22 @@ func (h *ResourceBase[implType]) Configure(ctx context.Context, req resource.Con
 	}
 	h.Provider = provider
 }
+
+// planPreparer is implemented by models which need more than the plan before they are applied,
+// such as write-only values which are only in the config. tfsdk resets the whole model when it
+// reads the plan, so it is called afterwards.
+type planPreparer interface {
+	preparePlan(ctx context.Context, provider *KubeProvider, config tfsdk.Config) diag.Diagnostics
+}
+
+func (h *ResourceBase[implType]) preparePlan(ctx context.Context, plan implType, config tfsdk.Config) diag.Diagnostics {
+	preparer, ok := any(plan).(planPreparer)
+	if !ok {
+		return nil
+	}
+	return preparer.preparePlan(ctx, h.Provider, config)
+}
+
 func (h *ResourceBase[implType]) NewResourceHelper(ctx context.Context, state implType) (*kube.ResourceHelper, error) {
 
 	resourceOptions := GetPtrToEmbedddedType[tfparts.APIOptionsModel](state)
@@ -60,6 +77,7 @@ func (h *ResourceBase[implType]) NewResourceHelper(ctx context.Context, state im
 
 func (h *ResourceBase[implType]) Create(ctx context.Context, plan implType, req resource.CreateRequest, resp *resource.CreateResponse) {
 	resp.Diagnostics.Append(req.Plan.Get(ctx, plan)...)
+	resp.Diagnostics.Append(h.preparePlan(ctx, plan, req.Config)...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
@@ -136,6 +154,7 @@ func (h *ResourceBase[implType]) Fetch(ctx context.Context, resourceBase *kube.R
 
 func (h *ResourceBase[implType]) Update(ctx context.Context, plan implType, req resource.UpdateRequest, resp *resource.UpdateResponse) {
 	resp.Diagnostics.Append(req.Plan.Get(ctx, plan)...)
+	resp.Diagnostics.Append(h.preparePlan(ctx, plan, req.Config)...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
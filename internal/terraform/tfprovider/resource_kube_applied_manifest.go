@@ -5,20 +5,32 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
 
 	"github.com/davidjspooner/terraform-provider-kubernetes/internal/generic/kube"
 	"github.com/davidjspooner/terraform-provider-kubernetes/internal/generic/vpath"
 	"github.com/davidjspooner/terraform-provider-kubernetes/internal/terraform/tfparts"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 )
 
 // Ensure provider defined types fully satisfy framework interfaces.
 var _ resource.Resource = &ResourceKubeResource{}
 var _ resource.ResourceWithImportState = &ResourceKubeResource{}
+var _ resource.ResourceWithModifyPlan = &ResourceKubeResource{}
+var _ resource.ResourceWithValidateConfig = &ResourceKubeResource{}
 
 func init() {
 	// Register the resource with the provider.
@@ -27,14 +39,42 @@ func init() {
 		r.ResourceBase.tfTypeNameSuffix = "_applied_manifest"
 		attr := map[string]schema.Attribute{
 			"manifest": schema.DynamicAttribute{
-				MarkdownDescription: "Manifest to apply",
+				MarkdownDescription: "Manifest to apply. Each value is converted to the type the OpenAPI schema of the cluster gives its field, so labels, annotations and env values stay strings. Fields the schema does not describe fall back to guessing the type from the text. Values may be unknown while planning. apiVersion, kind, metadata.name and metadata.namespace must be known when planning changes to an existing object, but when the object is created they are only required to be known at apply, when any value which is still unknown is an error",
 				Optional:            true,
 			},
+			"manifest_yaml": schema.StringAttribute{
+				MarkdownDescription: "Manifest to apply as yaml or json text, used instead of manifest. Values keep the types they have in the text. Changes to formatting and key order are not treated as drift",
+				Optional:            true,
+				CustomType:          tfparts.YamlManifestType{},
+			},
+			"sensitive_manifest": schema.DynamicAttribute{
+				MarkdownDescription: "Write-only values which are deep merged into the manifest and applied with it, but never stored in state. A field may not be set in both manifest and sensitive_manifest. Terraform does not see changes to write-only values, so a change to sensitive_manifest alone plans nothing: change sensitive_manifest_version with it. The data and stringData of a Secret are the exception, as their hashes in secret_data_hashes change. Any apply of the resource applies the current values. Requires terraform 1.11 or later",
+				Optional:            true,
+				WriteOnly:           true,
+			},
+			"sensitive_manifest_version": schema.StringAttribute{
+				MarkdownDescription: "Any value. Changing it applies the manifest with the current sensitive_manifest, removing any values which were taken out of it",
+				Optional:            true,
+			},
+			"redact_secret_data": schema.BoolAttribute{
+				MarkdownDescription: "Keep the values of a v1 Secret out of state and plan. data and stringData must then be set in sensitive_manifest rather than manifest, and only their hashes are stored, in secret_data_hashes. Set it to false to allow the values in manifest, where terraform stores them in plain text ( defaults to true )",
+				Optional:            true,
+			},
+			"secret_data_hashes": schema.MapAttribute{
+				MarkdownDescription: "hmac sha256, keyed with secret_data_salt, of the value of each key of data and stringData when the manifest is a Secret. A value changed in the configuration or in the cluster shows as a changed hash, without showing the value. Keys which are not configured are left out",
+				ElementType:         types.StringType,
+				Computed:            true,
+			},
+			"secret_data_salt": schema.StringAttribute{
+				MarkdownDescription: "Random key of the hmac in secret_data_hashes, generated when the resource is created",
+				Computed:            true,
+				PlanModifiers:       []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+			},
 		}
 
 		r.schema = schema.Schema{
 			// This description is used by the documentation generator and the language server.
-			MarkdownDescription: "Generic Manifest resource. This resource allows you to apply any Kubernetes manifest to the cluster. ",
+			MarkdownDescription: "Generic Manifest resource. This resource allows you to apply any Kubernetes manifest to the cluster. When the object is created the manifest may depend on values which are not known until apply, such as `yamldecode(templatefile(...))` of another resource's attributes, and computed attributes are then unknown in the plan. Once the object exists apiVersion, kind, metadata.name and metadata.namespace must be known when planning, so the object can be found",
 
 			Attributes: MergeResourceAttributes(
 				attr,
@@ -54,29 +94,246 @@ type ResourceKubeResource struct {
 
 // ManifestResourceModel describes the resource data model.
 type ManifestResourceModel struct {
-	Manifest types.Dynamic `tfsdk:"manifest"`
+	Manifest                 types.Dynamic             `tfsdk:"manifest"`
+	ManifestYaml             tfparts.YamlManifestValue `tfsdk:"manifest_yaml"`
+	SensitiveManifest        types.Dynamic             `tfsdk:"sensitive_manifest"`
+	SensitiveManifestVersion types.String              `tfsdk:"sensitive_manifest_version"`
+	RedactSecretData         types.Bool                `tfsdk:"redact_secret_data"`
+	SecretDataHashes         types.Map                 `tfsdk:"secret_data_hashes"`
+	SecretDataSalt           types.String              `tfsdk:"secret_data_salt"`
+
+	// configSensitiveManifest is read from the config because write-only values are null in the plan.
+	configSensitiveManifest types.Dynamic
+	// fieldTypes decides the types of values in manifest and sensitive_manifest.
+	fieldTypes kube.FieldTypeResolver
+	// allowUnknown is set while validating and planning, when parts of the manifest may not be
+	// known yet. They are converted to tfparts.Unknown.
+	allowUnknown bool
 
 	tfparts.APIOptionsModel
 	tfparts.FetchMap
 }
 
+// configuredManifest returns the manifest from either manifest or manifest_yaml.
+func (model *ManifestResourceModel) configuredManifest(ctx context.Context) (unstructured.Unstructured, error) {
+	if model.ManifestYaml.IsNull() {
+		converter := tfparts.ManifestConverter{
+			FieldTypes:   model.fieldTypes,
+			AllowUnknown: model.allowUnknown,
+		}
+		return converter.ToUnstructured(ctx, model.Manifest)
+	}
+	if !model.Manifest.IsNull() {
+		return unstructured.Unstructured{}, fmt.Errorf("only one of manifest and manifest_yaml may be set")
+	}
+	return model.ManifestYaml.Manifest()
+}
+
+// BuildManifest returns the configured manifest with sensitive_manifest deep merged into it, so
+// both are applied together by one field manager.
 func (model *ManifestResourceModel) BuildManifest(manifest *unstructured.Unstructured) error {
 	var err error
 	ctx := context.Background()
-	*manifest, err = tfparts.DynamicValueToUnstructured(ctx, model.Manifest)
+	*manifest, err = model.configuredManifest(ctx)
 	if err != nil {
-		if errors.Is(err, io.EOF) {
-			// This is a special case where the manifest is empty
-			// and we want to return a nil error.
-			*manifest = unstructured.Unstructured{}
-			return nil
+		if !errors.Is(err, io.EOF) {
+			return err
 		}
+		// This is a special case where the manifest is empty
+		// and we want to return a nil error.
+		*manifest = unstructured.Unstructured{}
+	}
+	if model.configSensitiveManifest.IsNull() || manifest.Object == nil {
+		return nil
+	}
+	sensitive, err := model.sensitiveValues(ctx, *manifest)
+	if err != nil {
+		return err
+	}
+	err = checkSensitiveOverlap(*manifest, sensitive)
+	if err != nil {
 		return err
 	}
+	if sensitive.Object != nil {
+		kube.DeepMerge(manifest.Object, sensitive.Object)
+	}
+	return nil
+}
+
+// sensitiveValues converts sensitive_manifest using the types of the fields of manifest.
+func (model *ManifestResourceModel) sensitiveValues(ctx context.Context, manifest unstructured.Unstructured) (unstructured.Unstructured, error) {
+	converter := tfparts.ManifestConverter{
+		FieldTypes:   model.fieldTypes,
+		GVK:          manifest.GroupVersionKind(),
+		AllowUnknown: model.allowUnknown,
+	}
+	sensitive, err := converter.ToUnstructured(ctx, model.configSensitiveManifest)
+	if err != nil {
+		return sensitive, fmt.Errorf("invalid sensitive_manifest: %w", err)
+	}
+	return sensitive, nil
+}
+
+func readSensitiveManifest(ctx context.Context, config tfsdk.Config, model *ManifestResourceModel) diag.Diagnostics {
+	return config.GetAttribute(ctx, path.Root("sensitive_manifest"), &model.configSensitiveManifest)
+}
+
+// preparePlan sets the values which are not part of the plan before it is applied.
+func (model *ManifestResourceModel) preparePlan(ctx context.Context, provider *KubeProvider, config tfsdk.Config) diag.Diagnostics {
+	model.fieldTypes = providerFieldTypes(ctx, provider)
+	return readSensitiveManifest(ctx, config, model)
+}
+
+// setSecretDataHashes records the hashes of the values of the keys of a Secret. Only the keys
+// which are configured are hashed, so keys added by controllers do not show as drift. The hash of
+// a value which is not known yet is unknown, as are all hashes until the salt is generated.
+func (model *ManifestResourceModel) setSecretDataHashes(u unstructured.Unstructured, keys map[string]bool) error {
+	model.SecretDataHashes = types.MapNull(types.StringType)
+	if !kube.IsSecret(u) {
+		return nil
+	}
+	known, unknownKeys, ok := knownSecretValues(u)
+	if !ok {
+		model.SecretDataHashes = types.MapUnknown(types.StringType)
+		return nil
+	}
+	elements := make(map[string]attr.Value, len(keys))
+	if model.SecretDataSalt.IsUnknown() || model.SecretDataSalt.IsNull() {
+		for key := range keys {
+			elements[key] = types.StringUnknown()
+		}
+	} else {
+		hashes, err := kube.SecretDataHashes(model.SecretDataSalt.ValueString(), known)
+		if err != nil {
+			return err
+		}
+		for key := range keys {
+			if hash, found := hashes[key]; found {
+				elements[key] = types.StringValue(hash)
+			}
+		}
+		for _, key := range unknownKeys {
+			elements[key] = types.StringUnknown()
+		}
+	}
+	var diags diag.Diagnostics
+	model.SecretDataHashes, diags = types.MapValue(types.StringType, elements)
+	return tfparts.DiagsToGoError(diags)
+}
+
+// checkRedactedSecret rejects the values of a Secret in manifest unless redact_secret_data is
+// false, since terraform stores manifest in state and plan.
+func (model *ManifestResourceModel) checkRedactedSecret(manifest unstructured.Unstructured) error {
+	if model.RedactSecretData.Equal(types.BoolValue(false)) || model.RedactSecretData.IsUnknown() || !kube.IsSecret(manifest) {
+		return nil
+	}
+	var fields []string
+	for _, field := range []string{"data", "stringData"} {
+		if manifest.Object[field] != nil {
+			fields = append(fields, field)
+		}
+	}
+	if len(fields) > 0 {
+		return fmt.Errorf("the values of a Secret would be stored in state, set %s in sensitive_manifest instead or set redact_secret_data to false", strings.Join(fields, " and "))
+	}
 	return nil
 }
+
+// knownSecretValues returns a copy of a Secret without the values which are not known yet, and
+// the keys whose value is therefore unknown. It returns false when data or stringData as a whole
+// is unknown.
+func knownSecretValues(u unstructured.Unstructured) (unstructured.Unstructured, []string, bool) {
+	known := unstructured.Unstructured{Object: maps.Clone(u.Object)}
+	stringData, _ := known.Object["stringData"].(map[string]any)
+	var unknownKeys []string
+	for _, field := range []string{"data", "stringData"} {
+		value, found := known.Object[field]
+		if tfparts.IsUnknown(value) {
+			return known, nil, false
+		}
+		values, ok := value.(map[string]any)
+		if !found || !ok {
+			continue
+		}
+		values = maps.Clone(values)
+		for key, v := range values {
+			if !tfparts.IsUnknown(v) {
+				continue
+			}
+			delete(values, key)
+			// a known value in stringData replaces an unknown one in data
+			override, replaced := stringData[key]
+			if field == "data" && replaced && !tfparts.IsUnknown(override) {
+				continue
+			}
+			unknownKeys = append(unknownKeys, key)
+		}
+		known.Object[field] = values
+	}
+	return known, unknownKeys, true
+}
+
+// checkSensitiveOverlap rejects fields which are set in both manifest and sensitive_manifest, as
+// the merge would quietly replace one with the other. A Secret key in data and stringData is the
+// same value, so it may not be set in both either.
+func checkSensitiveOverlap(manifest, sensitive unstructured.Unstructured) error {
+	if sensitive.Object == nil {
+		return nil
+	}
+	duplicates := kube.OverlappingPaths(manifest.Object, sensitive.Object)
+	if kube.IsSecret(manifest) {
+		configuredKeys := secretKeys(manifest)
+		for key := range secretKeys(sensitive) {
+			if configuredKeys[key] && !slices.Contains(duplicates, "data."+key) && !slices.Contains(duplicates, "stringData."+key) {
+				duplicates = append(duplicates, "key "+key)
+			}
+		}
+	}
+	if len(duplicates) > 0 {
+		sort.Strings(duplicates)
+		return fmt.Errorf("%s set in both manifest and sensitive_manifest", strings.Join(duplicates, ", "))
+	}
+	return nil
+}
+
+// secretKeys returns the keys of data and stringData, including those with unknown values.
+func secretKeys(u unstructured.Unstructured) map[string]bool {
+	keys := make(map[string]bool)
+	for _, field := range []string{"data", "stringData"} {
+		values, _ := u.Object[field].(map[string]any)
+		for key := range values {
+			keys[key] = true
+		}
+	}
+	return keys
+}
+
 func (model *ManifestResourceModel) UpdateFrom(manifest unstructured.Unstructured) error {
 	ctx := context.Background()
+	if model.SecretDataSalt.IsNull() || model.SecretDataSalt.IsUnknown() {
+		salt, err := kube.NewHashSalt()
+		if err != nil {
+			return err
+		}
+		model.SecretDataSalt = types.StringValue(salt)
+	}
+	if manifest.Object != nil {
+		// sensitive_manifest is only known while applying, otherwise the keys which were
+		// hashed before are hashed again
+		var configured unstructured.Unstructured
+		err := model.BuildManifest(&configured)
+		if err != nil {
+			return err
+		}
+		keys := secretKeys(configured)
+		for key := range model.SecretDataHashes.Elements() {
+			keys[key] = true
+		}
+		err = model.setSecretDataHashes(manifest, keys)
+		if err != nil {
+			return err
+		}
+	}
 	previousManifest, err := tfparts.DynamicValueToUnstructured(ctx, model.Manifest)
 	if err != nil {
 		return err
@@ -113,16 +370,18 @@ func (model *ManifestResourceModel) UpdateFrom(manifest unstructured.Unstructure
 }
 func (model *ManifestResourceModel) GetResouceKey() (kube.ResourceKey, error) {
 	ctx := context.Background()
-	manifest, err := tfparts.DynamicValueToUnstructured(ctx, model.Manifest)
+	manifest, err := model.configuredManifest(ctx)
 	if err != nil {
-		return kube.ResourceKey{}, nil
+		return kube.ResourceKey{}, fmt.Errorf("invalid manifest: %w", err)
 	}
 
+	// checked here as well as when validating, since an unknown manifest is only known at apply
+	missing := tfparts.MissingIdentityFields(manifest.Object)
+	if len(missing) > 0 {
+		return kube.ResourceKey{}, fmt.Errorf("the manifest must set %s", strings.Join(missing, ", "))
+	}
 	namespace := manifest.GetNamespace()
 	name := manifest.GetName()
-	if name == "" {
-		return kube.ResourceKey{}, fmt.Errorf("name is empty")
-	}
 	k := kube.ResourceKey{
 		ApiVersion: manifest.GetAPIVersion(),
 		Kind:       manifest.GetKind(),
@@ -134,6 +393,14 @@ func (model *ManifestResourceModel) GetResouceKey() (kube.ResourceKey, error) {
 	return k, nil
 }
 
+// providerFieldTypes uses the schema of the cluster when the provider is configured.
+func providerFieldTypes(ctx context.Context, provider *KubeProvider) kube.FieldTypeResolver {
+	if provider == nil {
+		return kube.BuiltinFieldTypes
+	}
+	return provider.Shared.FieldTypes(ctx)
+}
+
 func (r *ResourceKubeResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
 	r.ResourceBase.Metadata(ctx, req, resp)
 }
@@ -146,6 +413,116 @@ func (r *ResourceKubeResource) Configure(ctx context.Context, req resource.Confi
 	r.ResourceBase.Configure(ctx, req, resp)
 }
 
+// manifestIsUnknown tells whether the whole manifest is unknown, so nothing can be checked yet.
+func (model *ManifestResourceModel) manifestIsUnknown() bool {
+	return model.Manifest.IsUnknown() || model.Manifest.IsUnderlyingValueUnknown() || model.ManifestYaml.IsUnknown()
+}
+
+// ValidateConfig checks the identity fields which are already known. Unknown values are checked
+// again when planning.
+func (r *ResourceKubeResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	config := &ManifestResourceModel{allowUnknown: true}
+	resp.Diagnostics.Append(req.Config.Get(ctx, config)...)
+	if resp.Diagnostics.HasError() || config.manifestIsUnknown() {
+		return
+	}
+	if config.Manifest.IsNull() && config.ManifestYaml.IsNull() {
+		resp.Diagnostics.AddAttributeError(path.Root("manifest"), "Missing manifest", "One of manifest and manifest_yaml must be set")
+		return
+	}
+	manifest, err := config.configuredManifest(ctx)
+	if err != nil {
+		// errors in the text of manifest_yaml are reported by its type
+		if config.ManifestYaml.IsNull() || !config.Manifest.IsNull() {
+			resp.Diagnostics.AddAttributeError(path.Root("manifest"), "Invalid manifest", err.Error())
+		}
+		return
+	}
+	for _, field := range tfparts.MissingIdentityFields(manifest.Object) {
+		resp.Diagnostics.AddAttributeError(path.Root("manifest"), "Invalid manifest", fmt.Sprintf("The manifest must set %s", field))
+	}
+	err = config.checkRedactedSecret(manifest)
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("manifest"), "Secret values in manifest", err.Error())
+	}
+}
+
+// ModifyPlan requires the identity fields of the manifest to be known once the object exists,
+// because it can not be found without them. Other values may be unknown. When the object is
+// created everything may be unknown, and the checks wait for apply. It also plans the hashes of the
+// configured Secret values, including those in sensitive_manifest, so a value which has changed
+// in the configuration or drifted from it shows as a changed hash and a value which is not known
+// yet has an unknown hash. Other values of sensitive_manifest are not hashed, so they can change
+// without showing a diff. sensitive_manifest may not set the fields of manifest.
+func (r *ResourceKubeResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+	plan := &ManifestResourceModel{allowUnknown: true}
+	resp.Diagnostics.Append(req.Plan.Get(ctx, plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	creating := req.State.Raw.IsNull()
+	if plan.manifestIsUnknown() {
+		if creating {
+			// computed attributes stay unknown until apply
+			return
+		}
+		resp.Diagnostics.AddAttributeError(path.Root("manifest"), "Manifest is unknown",
+			"The manifest depends on values which are not known until apply. At least apiVersion, kind, metadata.name and metadata.namespace must be known when planning changes to an existing object")
+		return
+	}
+	resp.Diagnostics.Append(readSensitiveManifest(ctx, req.Config, plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	plan.fieldTypes = providerFieldTypes(ctx, r.Provider)
+	manifest, err := plan.configuredManifest(ctx)
+	if err != nil && !errors.Is(err, io.EOF) {
+		resp.Diagnostics.AddError("Invalid manifest", err.Error())
+		return
+	}
+	unknownFields := tfparts.UnknownIdentityFields(manifest.Object)
+	if creating && len(unknownFields) > 0 {
+		return
+	}
+	for _, field := range unknownFields {
+		resp.Diagnostics.AddAttributeError(path.Root("manifest"), "Unknown identity field",
+			fmt.Sprintf("%s depends on values which are not known until apply. It must be known when planning changes to an existing object so the object can be found", field))
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	sensitive, err := plan.sensitiveValues(ctx, manifest)
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("sensitive_manifest"), "Invalid sensitive manifest", err.Error())
+		return
+	}
+	err = checkSensitiveOverlap(manifest, sensitive)
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("sensitive_manifest"), "Duplicate field", err.Error())
+		return
+	}
+	err = plan.checkRedactedSecret(manifest)
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("manifest"), "Secret values in manifest", err.Error())
+		return
+	}
+	var merged unstructured.Unstructured
+	err = plan.BuildManifest(&merged)
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid manifest", err.Error())
+		return
+	}
+	err = plan.setSecretDataHashes(merged, secretKeys(merged))
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to hash secret data", err.Error())
+		return
+	}
+	resp.Diagnostics.Append(resp.Plan.Set(ctx, plan)...)
+}
+
 func (r *ResourceKubeResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
 	plan := &ManifestResourceModel{}
 	r.ResourceBase.Create(ctx, plan, req, resp)
@@ -0,0 +1,116 @@
+package tfprovider
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/davidjspooner/terraform-provider-kubernetes/internal/generic/kube"
+	"github.com/davidjspooner/terraform-provider-kubernetes/internal/terraform/tfparts"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestCheckRedactedSecret(t *testing.T) {
+	testCases := []struct {
+		name     string
+		manifest string
+		redact   types.Bool
+		err      bool
+	}{
+		{"secret data", "apiVersion: v1\nkind: Secret\nmetadata:\n  name: s\ndata:\n  a: Yg==\n", types.BoolNull(), true},
+		{"secret string data", "apiVersion: v1\nkind: Secret\nmetadata:\n  name: s\nstringData:\n  a: b\n", types.BoolValue(true), true},
+		{"opt out", "apiVersion: v1\nkind: Secret\nmetadata:\n  name: s\ndata:\n  a: Yg==\n", types.BoolValue(false), false},
+		{"secret without values", "apiVersion: v1\nkind: Secret\nmetadata:\n  name: s\ntype: Opaque\n", types.BoolNull(), false},
+		{"config map", "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: c\ndata:\n  a: b\n", types.BoolNull(), false},
+	}
+	for _, tc := range testCases {
+		manifest, err := kube.ParseSingleYamlManifest(tc.manifest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		model := &ManifestResourceModel{RedactSecretData: tc.redact}
+		err = model.checkRedactedSecret(manifest)
+		if (err != nil) != tc.err {
+			t.Errorf("%s: expected error=%v, got %v", tc.name, tc.err, err)
+		}
+	}
+}
+
+func TestSetSecretDataHashes(t *testing.T) {
+	live, err := kube.ParseSingleYamlManifest("apiVersion: v1\nkind: Secret\nmetadata:\n  name: s\ndata:\n  a: Yg==\n  b: Yw==\n  added: ZA==\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := map[string]bool{"a": true, "b": true, "missing": true}
+
+	model := &ManifestResourceModel{SecretDataSalt: types.StringValue("salt")}
+	err = model.setSecretDataHashes(live, keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := kube.SecretDataHashes("salt", live)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hashes := model.SecretDataHashes.Elements()
+	if len(hashes) != 2 || hashes["a"] != types.StringValue(expected["a"]) || hashes["b"] != types.StringValue(expected["b"]) {
+		t.Errorf("expected hashes of the configured keys which exist, got %v", hashes)
+	}
+
+	model = &ManifestResourceModel{SecretDataSalt: types.StringUnknown()}
+	err = model.setSecretDataHashes(live, keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for key, hash := range model.SecretDataHashes.Elements() {
+		if !hash.IsUnknown() {
+			t.Errorf("expected the hash of %s to be unknown before the salt is generated, got %v", key, hash)
+		}
+	}
+	if len(model.SecretDataHashes.Elements()) != len(keys) {
+		t.Errorf("expected a hash for each key, got %v", model.SecretDataHashes)
+	}
+}
+
+func TestUnknownIdentityAtApply(t *testing.T) {
+	ctx := context.Background()
+	metadataType := map[string]attr.Type{"name": types.StringType, "namespace": types.StringType}
+	manifestType := map[string]attr.Type{
+		"apiVersion": types.StringType,
+		"kind":       types.StringType,
+		"metadata":   types.ObjectType{AttrTypes: metadataType},
+	}
+	manifest := types.ObjectValueMust(manifestType, map[string]attr.Value{
+		"apiVersion": types.StringValue("v1"),
+		"kind":       types.StringValue("ConfigMap"),
+		"metadata": types.ObjectValueMust(metadataType, map[string]attr.Value{
+			"name":      types.StringUnknown(),
+			"namespace": types.StringValue("default"),
+		}),
+	})
+
+	// planning a create keeps the unknown name and leaves the check for apply
+	plan := &ManifestResourceModel{Manifest: types.DynamicValue(manifest), allowUnknown: true}
+	configured, err := plan.configuredManifest(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unknown := tfparts.UnknownIdentityFields(configured.Object)
+	if !reflect.DeepEqual(unknown, []string{"metadata.name"}) {
+		t.Errorf("expected metadata.name to be unknown while planning, got %v", unknown)
+	}
+
+	// create rejects a name which is still unknown at apply
+	apply := &ManifestResourceModel{Manifest: types.DynamicValue(manifest)}
+	_, err = apply.GetResouceKey()
+	if err == nil {
+		t.Errorf("expected an error for a name which is unknown at apply")
+	}
+	var u unstructured.Unstructured
+	err = apply.BuildManifest(&u)
+	if err == nil {
+		t.Errorf("expected an error building a manifest with an unknown name at apply")
+	}
+}
@@ -0,0 +1,390 @@
+package tfprovider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/davidjspooner/terraform-provider-kubernetes/internal/generic/kube"
+	"github.com/davidjspooner/terraform-provider-kubernetes/internal/terraform/tfparts"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ resource.ResourceWithConfigure = &ResourceKubeConfigGenerator{}
+var _ resource.ResourceWithModifyPlan = &ResourceKubeConfigGenerator{}
+var _ resource.ResourceWithValidateConfig = &ResourceKubeConfigGenerator{}
+
+func init() {
+	// Register the resource with the provider.
+	RegisterResource(func() resource.Resource {
+		return &ResourceKubeConfigGenerator{
+			tfTypeNameSuffix: "_config_generator",
+		}
+	})
+}
+
+// ResourceKubeConfigGenerator applies a ConfigMap or Secret whose name ends with a hash of its content.
+type ResourceKubeConfigGenerator struct {
+	provider         *KubeProvider
+	tfTypeNameSuffix string
+}
+
+// ConfigGeneratorModel describes the resource data model.
+type ConfigGeneratorModel struct {
+	Kind          types.String `tfsdk:"kind"`
+	Name          types.String `tfsdk:"name"`
+	Namespace     types.String `tfsdk:"namespace"`
+	Type          types.String `tfsdk:"type"`
+	Labels        types.Map    `tfsdk:"labels"`
+	Annotations   types.Map    `tfsdk:"annotations"`
+	Literals      types.Map    `tfsdk:"literals"`
+	KeepVersions  types.Int64  `tfsdk:"keep_versions"`
+	GeneratedName types.String `tfsdk:"generated_name"`
+	Hash          types.String `tfsdk:"hash"`
+	Versions      types.List   `tfsdk:"versions"`
+
+	tfparts.FileSetModelList
+	tfparts.APIOptionsModel
+}
+
+func (model *ConfigGeneratorModel) newGenerator(ctx context.Context) (*kube.ConfigGenerator, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	g := &kube.ConfigGenerator{
+		Kind:      model.Kind.ValueString(),
+		Name:      model.Name.ValueString(),
+		Namespace: model.Namespace.ValueString(),
+		Type:      model.Type.ValueString(),
+	}
+	diags.Append(model.Labels.ElementsAs(ctx, &g.Labels, false)...)
+	diags.Append(model.Annotations.ElementsAs(ctx, &g.Annotations, false)...)
+	var literals map[string]string
+	diags.Append(model.Literals.ElementsAs(ctx, &literals, false)...)
+	if diags.HasError() {
+		return nil, diags
+	}
+	for key, value := range literals {
+		err := g.Data.AddText(key, value)
+		if err != nil {
+			diags.AddError("Invalid literal", err.Error())
+			return nil, diags
+		}
+	}
+	err := g.AddFiles(model.GetFileSetDefs())
+	if err != nil {
+		diags.AddError("Error expanding file set", err.Error())
+		return nil, diags
+	}
+	return g, diags
+}
+
+// generate builds the object and records its name, hash and the versions to keep. It returns
+// the versions which are no longer kept.
+func (model *ConfigGeneratorModel) generate(ctx context.Context, previous []string) (unstructured.Unstructured, []string, diag.Diagnostics) {
+	g, diags := model.newGenerator(ctx)
+	if diags.HasError() {
+		return unstructured.Unstructured{}, nil, diags
+	}
+	u, hash, err := g.Generate()
+	if err != nil {
+		diags.AddError("Failed to generate "+u.GetKind(), err.Error())
+		return u, nil, diags
+	}
+	keep := kube.DefaultKeepVersions
+	if !model.KeepVersions.IsNull() {
+		keep = int(model.KeepVersions.ValueInt64())
+	}
+	kept, pruned := kube.KeepVersions(previous, u.GetName(), keep)
+	model.GeneratedName = types.StringValue(u.GetName())
+	model.Hash = types.StringValue(hash)
+	var d diag.Diagnostics
+	model.Versions, d = types.ListValueFrom(ctx, types.StringType, kept)
+	diags.Append(d...)
+	return u, pruned, diags
+}
+
+func (model *ConfigGeneratorModel) versions(ctx context.Context) ([]string, diag.Diagnostics) {
+	var versions []string
+	if model.Versions.IsNull() || model.Versions.IsUnknown() {
+		return nil, nil
+	}
+	diags := model.Versions.ElementsAs(ctx, &versions, false)
+	return versions, diags
+}
+
+// generatedObject adapts an object which is already built to kube.StateInteraface.
+type generatedObject struct {
+	manifest unstructured.Unstructured
+	current  unstructured.Unstructured
+	found    bool
+}
+
+func (o *generatedObject) GetResouceKey() (kube.ResourceKey, error) {
+	return *kube.GetKey(o.manifest), nil
+}
+
+func (o *generatedObject) BuildManifest(manifest *unstructured.Unstructured) error {
+	*manifest = o.manifest
+	return nil
+}
+
+func (o *generatedObject) UpdateFrom(manifest unstructured.Unstructured) error {
+	o.current = manifest
+	o.found = manifest.Object != nil
+	return nil
+}
+
+func (model *ConfigGeneratorModel) versionObject(name string) *generatedObject {
+	u := unstructured.Unstructured{Object: map[string]any{}}
+	u.SetAPIVersion("v1")
+	u.SetKind(kube.FirstNonNullString(model.Kind.ValueString(), "ConfigMap"))
+	u.SetNamespace(model.Namespace.ValueString())
+	u.SetName(name)
+	return &generatedObject{manifest: u}
+}
+
+func newObjectHelper(ctx context.Context, provider *KubeProvider, apiOptions *tfparts.APIOptionsModel, object *generatedObject) (*kube.ResourceHelper, error) {
+	options, err := kube.MergeAPIOptions(provider.DefaultApiOptions, apiOptions.Options())
+	if err != nil {
+		return nil, err
+	}
+	key, err := object.GetResouceKey()
+	if err != nil {
+		return nil, err
+	}
+	return kube.NewResourceHelper(ctx, &provider.Shared, options, key)
+}
+
+func (r *ResourceKubeConfigGenerator) deleteVersions(ctx context.Context, model *ConfigGeneratorModel, names []string) error {
+	for _, name := range names {
+		object := model.versionObject(name)
+		helper, err := newObjectHelper(ctx, r.provider, &model.APIOptionsModel, object)
+		if err != nil {
+			return err
+		}
+		err = helper.Delete(ctx, object)
+		if err != nil {
+			return fmt.Errorf("error deleting %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func (r *ResourceKubeConfigGenerator) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + r.tfTypeNameSuffix
+}
+
+func (r *ResourceKubeConfigGenerator) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	attr := map[string]schema.Attribute{
+		"kind": schema.StringAttribute{
+			MarkdownDescription: "ConfigMap or Secret ( defaults to ConfigMap )",
+			Optional:            true,
+			PlanModifiers:       []planmodifier.String{stringplanmodifier.RequiresReplace()},
+		},
+		"name": schema.StringAttribute{
+			MarkdownDescription: "Name which the content hash is appended to",
+			Required:            true,
+			PlanModifiers:       []planmodifier.String{stringplanmodifier.RequiresReplace()},
+		},
+		"namespace": schema.StringAttribute{
+			MarkdownDescription: "Namespace of the object",
+			Optional:            true,
+			PlanModifiers:       []planmodifier.String{stringplanmodifier.RequiresReplace()},
+		},
+		"type": schema.StringAttribute{
+			MarkdownDescription: "Type of the secret ( defaults to Opaque )",
+			Optional:            true,
+		},
+		"labels": schema.MapAttribute{
+			MarkdownDescription: "Labels of the object",
+			ElementType:         types.StringType,
+			Optional:            true,
+		},
+		"annotations": schema.MapAttribute{
+			MarkdownDescription: "Annotations of the object",
+			ElementType:         types.StringType,
+			Optional:            true,
+		},
+		"literals": schema.MapAttribute{
+			MarkdownDescription: "Text values added alongside the files. Sensitive because they may be the values of a Secret",
+			ElementType:         types.StringType,
+			Optional:            true,
+			Sensitive:           true,
+		},
+		"keep_versions": schema.Int64Attribute{
+			MarkdownDescription: "Number of versions to keep so pods which are rolling can still mount the previous content. Older versions are deleted ( defaults to 3 )",
+			Optional:            true,
+		},
+		"generated_name": schema.StringAttribute{
+			MarkdownDescription: "Name of the object including the content hash. Reference this from workloads so they roll when the content changes",
+			Computed:            true,
+		},
+		"hash": schema.StringAttribute{
+			MarkdownDescription: "Hash of the content",
+			Computed:            true,
+		},
+		"versions": schema.ListAttribute{
+			MarkdownDescription: "Names of the versions which are kept, newest first",
+			ElementType:         types.StringType,
+			Computed:            true,
+		},
+	}
+	resp.Schema = schema.Schema{
+		// This description is used by the documentation generator and the language server.
+		MarkdownDescription: "Generates an immutable ConfigMap or Secret from files and literals, in the style of the kustomize configMapGenerator and secretGenerator. Files are keyed by their base name and two files with the same base name are an error. Text is stored in data or stringData and other content in binaryData or data",
+
+		Attributes: MergeResourceAttributes(
+			attr,
+			tfparts.FileSetsResourceAttributes(false),
+			tfparts.ApiOptionsResourceAttributes(),
+		),
+	}
+}
+
+func (r *ResourceKubeConfigGenerator) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+	provider, ok := req.ProviderData.(*KubeProvider)
+	if !ok {
+		resp.Diagnostics.AddError("Unexpected Type", "Expected provider data to be of type *KubeProvider")
+		return
+	}
+	r.provider = provider
+}
+
+// ValidateConfig rejects the file set options which apply to manifest documents, since each file
+// becomes a single key.
+func (r *ResourceKubeConfigGenerator) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	resp.Diagnostics.Append(tfparts.ValidateUnsplitDocuments(ctx, req.Config)...)
+}
+
+// ModifyPlan computes the generated name during plan so dependent workloads show the new name.
+func (r *ResourceKubeConfigGenerator) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if req.Plan.Raw.IsNull() || !req.Config.Raw.IsFullyKnown() {
+		return
+	}
+	var plan ConfigGeneratorModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	var previous []string
+	if !req.State.Raw.IsNull() {
+		var state ConfigGeneratorModel
+		resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		var diags diag.Diagnostics
+		previous, diags = state.versions(ctx)
+		resp.Diagnostics.Append(diags...)
+	}
+	_, _, diags := plan.generate(ctx, previous)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.Append(resp.Plan.Set(ctx, &plan)...)
+}
+
+func (r *ResourceKubeConfigGenerator) apply(ctx context.Context, plan *ConfigGeneratorModel, previous []string) diag.Diagnostics {
+	u, pruned, diags := plan.generate(ctx, previous)
+	if diags.HasError() {
+		return diags
+	}
+	object := &generatedObject{manifest: u}
+	helper, err := newObjectHelper(ctx, r.provider, &plan.APIOptionsModel, object)
+	if err != nil {
+		diags.AddError("Failed to create resource helper", err.Error())
+		return diags
+	}
+	err = helper.Create(ctx, object)
+	if err != nil {
+		diags.AddError("Apply failed", err.Error())
+		return diags
+	}
+	err = r.deleteVersions(ctx, plan, pruned)
+	if err != nil {
+		diags.AddError("Failed to delete old versions", err.Error())
+	}
+	return diags
+}
+
+func (r *ResourceKubeConfigGenerator) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan ConfigGeneratorModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.Append(r.apply(ctx, &plan, nil)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+func (r *ResourceKubeConfigGenerator) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state ConfigGeneratorModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	object := state.versionObject(state.GeneratedName.ValueString())
+	helper, err := newObjectHelper(ctx, r.provider, &state.APIOptionsModel, object)
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to create resource helper", err.Error())
+		return
+	}
+	_, err = helper.Fetch(ctx, object, nil, kube.MayOrMayNotExist)
+	if err != nil {
+		resp.Diagnostics.AddError("Fetch failed", err.Error())
+		return
+	}
+	if !object.found {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+}
+
+func (r *ResourceKubeConfigGenerator) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan, state ConfigGeneratorModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	previous, diags := state.versions(ctx)
+	resp.Diagnostics.Append(diags...)
+	resp.Diagnostics.Append(r.apply(ctx, &plan, previous)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+func (r *ResourceKubeConfigGenerator) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state ConfigGeneratorModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	versions, diags := state.versions(ctx)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	err := r.deleteVersions(ctx, &state, versions)
+	if err != nil {
+		resp.Diagnostics.AddError("Delete failed", err.Error())
+		return
+	}
+	resp.State.RemoveResource(ctx)
+}
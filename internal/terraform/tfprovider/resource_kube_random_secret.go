@@ -0,0 +1,337 @@
+package tfprovider
+
+import (
+	"context"
+	"encoding/base64"
+
+	"github.com/davidjspooner/terraform-provider-kubernetes/internal/generic/kube"
+	"github.com/davidjspooner/terraform-provider-kubernetes/internal/terraform/tfparts"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/boolplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ resource.ResourceWithConfigure = &ResourceKubeRandomSecret{}
+
+func init() {
+	// Register the resource with the provider.
+	RegisterResource(func() resource.Resource {
+		return &ResourceKubeRandomSecret{
+			tfTypeNameSuffix: "_random_secret",
+		}
+	})
+}
+
+// ResourceKubeRandomSecret makes sure a Secret has a random value for each key without storing
+// the values in state.
+type ResourceKubeRandomSecret struct {
+	provider         *KubeProvider
+	tfTypeNameSuffix string
+}
+
+// RandomSecretModel describes the resource data model.
+type RandomSecretModel struct {
+	Name           types.String `tfsdk:"name"`
+	Namespace      types.String `tfsdk:"namespace"`
+	Labels         types.Map    `tfsdk:"labels"`
+	Annotations    types.Map    `tfsdk:"annotations"`
+	Keys           types.List   `tfsdk:"keys"`
+	Length         types.Int64  `tfsdk:"length"`
+	Charset        types.String `tfsdk:"charset"`
+	Rotation       types.String `tfsdk:"rotation"`
+	RetainOnDelete types.Bool   `tfsdk:"retain_on_delete"`
+	Hash           types.String `tfsdk:"hash"`
+	Salt           types.String `tfsdk:"salt"`
+	Created        types.Bool   `tfsdk:"created"`
+
+	tfparts.APIOptionsModel
+}
+
+func (model *RandomSecretModel) options(ctx context.Context) (*kube.RandomSecretOptions, diag.Diagnostics) {
+	options := &kube.RandomSecretOptions{
+		Length:  kube.DefaultRandomLength,
+		Charset: kube.FirstNonNullString(model.Charset.ValueString(), kube.DefaultRandomCharset),
+	}
+	if !model.Length.IsNull() {
+		options.Length = int(model.Length.ValueInt64())
+	}
+	diags := model.Keys.ElementsAs(ctx, &options.Keys, false)
+	return options, diags
+}
+
+func (model *RandomSecretModel) secretObject() *generatedObject {
+	u := unstructured.Unstructured{Object: map[string]any{}}
+	u.SetAPIVersion("v1")
+	u.SetKind("Secret")
+	u.SetNamespace(model.Namespace.ValueString())
+	u.SetName(model.Name.ValueString())
+	return &generatedObject{manifest: u}
+}
+
+// setHash records the hash of the values, generating the salt the first time.
+func (model *RandomSecretModel) setHash(data map[string][]byte, keys []string) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if model.Salt.IsNull() || model.Salt.IsUnknown() {
+		salt, err := kube.NewHashSalt()
+		if err != nil {
+			diags.AddError("Failed to generate salt", err.Error())
+			return diags
+		}
+		model.Salt = types.StringValue(salt)
+	}
+	model.Hash = types.StringValue(kube.HashSecretValues(model.Salt.ValueString(), data, keys))
+	return diags
+}
+
+func (r *ResourceKubeRandomSecret) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + r.tfTypeNameSuffix
+}
+
+func (r *ResourceKubeRandomSecret) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	attr := map[string]schema.Attribute{
+		"name": schema.StringAttribute{
+			MarkdownDescription: "Name of the secret",
+			Required:            true,
+			PlanModifiers:       []planmodifier.String{stringplanmodifier.RequiresReplace()},
+		},
+		"namespace": schema.StringAttribute{
+			MarkdownDescription: "Namespace of the secret",
+			Optional:            true,
+			PlanModifiers:       []planmodifier.String{stringplanmodifier.RequiresReplace()},
+		},
+		"labels": schema.MapAttribute{
+			MarkdownDescription: "Labels of the secret",
+			ElementType:         types.StringType,
+			Optional:            true,
+		},
+		"annotations": schema.MapAttribute{
+			MarkdownDescription: "Annotations of the secret",
+			ElementType:         types.StringType,
+			Optional:            true,
+		},
+		"keys": schema.ListAttribute{
+			MarkdownDescription: "Keys which must have a value. A random value is generated for each key which is missing. Existing values are never overwritten and keys which are removed from the list are left in the secret",
+			ElementType:         types.StringType,
+			Required:            true,
+		},
+		"length": schema.Int64Attribute{
+			MarkdownDescription: "Length of each generated value ( defaults to 32 )",
+			Optional:            true,
+			Validators:          []validator.Int64{int64validator.AtLeast(1)},
+		},
+		"charset": schema.StringAttribute{
+			MarkdownDescription: "Characters used in generated values ( defaults to letters and digits )",
+			Optional:            true,
+			Validators:          []validator.String{stringvalidator.LengthAtLeast(1)},
+		},
+		"rotation": schema.StringAttribute{
+			MarkdownDescription: "Any value. Changing it regenerates every value in keys",
+			Optional:            true,
+		},
+		"retain_on_delete": schema.BoolAttribute{
+			MarkdownDescription: "Leave the secret in the cluster when the resource is destroyed. A secret which existed before the resource is always left ( defaults to false )",
+			Optional:            true,
+		},
+		"hash": schema.StringAttribute{
+			MarkdownDescription: "hmac sha256 of the values of the keys, keyed with salt. The values themselves are never stored in state",
+			Computed:            true,
+		},
+		"salt": schema.StringAttribute{
+			MarkdownDescription: "Random key of the hmac in hash, generated when the resource is created",
+			Computed:            true,
+			PlanModifiers:       []planmodifier.String{stringplanmodifier.UseStateForUnknown()},
+		},
+		"created": schema.BoolAttribute{
+			MarkdownDescription: "True when the resource created the secret rather than filling in one which already existed. Only a created secret is deleted with the resource",
+			Computed:            true,
+			PlanModifiers:       []planmodifier.Bool{boolplanmodifier.UseStateForUnknown()},
+		},
+	}
+	resp.Schema = schema.Schema{
+		// This description is used by the documentation generator and the language server.
+		MarkdownDescription: "Makes sure a Secret exists with a random value for each key. Values are generated in the provider and written straight to the cluster, so only a hash is stored in state",
+
+		Attributes: MergeResourceAttributes(
+			attr,
+			tfparts.ApiOptionsResourceAttributes(),
+		),
+	}
+}
+
+func (r *ResourceKubeRandomSecret) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+	provider, ok := req.ProviderData.(*KubeProvider)
+	if !ok {
+		resp.Diagnostics.AddError("Unexpected Type", "Expected provider data to be of type *KubeProvider")
+		return
+	}
+	r.provider = provider
+}
+
+// ensure fills in the missing values, or every value when regenerate is set, and records the hash.
+// Keys which were removed from keys are applied with their existing values so they are kept.
+func (r *ResourceKubeRandomSecret) ensure(ctx context.Context, model *RandomSecretModel, regenerate bool) diag.Diagnostics {
+	options, diags := model.options(ctx)
+	if diags.HasError() {
+		return diags
+	}
+	object := model.secretObject()
+	helper, err := newObjectHelper(ctx, r.provider, &model.APIOptionsModel, object)
+	if err != nil {
+		diags.AddError("Failed to create resource helper", err.Error())
+		return diags
+	}
+	_, err = helper.Fetch(ctx, object, nil, kube.MayOrMayNotExist)
+	if err != nil {
+		diags.AddError("Fetch failed", err.Error())
+		return diags
+	}
+	data, err := options.ApplyData(object.current, helper.FieldManager(), regenerate)
+	if err != nil {
+		diags.AddError("Failed to generate values", err.Error())
+		return diags
+	}
+
+	sm := kube.StringMap{}
+	for key, value := range data {
+		err = sm.AddBase64(key, base64.StdEncoding.EncodeToString(value))
+		if err != nil {
+			diags.AddError("Invalid key", err.Error())
+			return diags
+		}
+	}
+	var labels, annotations map[string]string
+	diags.Append(model.Labels.ElementsAs(ctx, &labels, false)...)
+	diags.Append(model.Annotations.ElementsAs(ctx, &annotations, false)...)
+	if diags.HasError() {
+		return diags
+	}
+	manifest := object.manifest
+	if len(labels) > 0 {
+		manifest.SetLabels(labels)
+	}
+	if len(annotations) > 0 {
+		manifest.SetAnnotations(annotations)
+	}
+	if !object.found {
+		manifest.Object["type"] = "Opaque"
+	}
+	manifest.Object["data"] = sm.GetUnstructuredBase64()
+	object.manifest = manifest
+
+	err = helper.Create(ctx, object)
+	if err != nil {
+		diags.AddError("Apply failed", err.Error())
+		return diags
+	}
+	if model.Created.IsNull() || model.Created.IsUnknown() {
+		model.Created = types.BoolValue(!object.found)
+	}
+	diags.Append(model.setHash(data, options.Keys)...)
+	return diags
+}
+
+func (r *ResourceKubeRandomSecret) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var plan RandomSecretModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.Append(r.ensure(ctx, &plan, false)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+// Read removes the resource when the secret or one of its keys is missing, so the next apply
+// fills it in again.
+func (r *ResourceKubeRandomSecret) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var state RandomSecretModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	options, diags := state.options(ctx)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	object := state.secretObject()
+	helper, err := newObjectHelper(ctx, r.provider, &state.APIOptionsModel, object)
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to create resource helper", err.Error())
+		return
+	}
+	_, err = helper.Fetch(ctx, object, nil, kube.MayOrMayNotExist)
+	if err != nil {
+		resp.Diagnostics.AddError("Fetch failed", err.Error())
+		return
+	}
+	data, err := kube.SecretData(object.current)
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to read secret", err.Error())
+		return
+	}
+	for _, key := range options.Keys {
+		if _, found := data[key]; !found {
+			resp.State.RemoveResource(ctx)
+			return
+		}
+	}
+	resp.Diagnostics.Append(state.setHash(data, options.Keys)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+}
+
+func (r *ResourceKubeRandomSecret) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var plan, state RandomSecretModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	regenerate := !plan.Rotation.Equal(state.Rotation)
+	resp.Diagnostics.Append(r.ensure(ctx, &plan, regenerate)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+func (r *ResourceKubeRandomSecret) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state RandomSecretModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	// a secret which existed before the resource is left for whatever created it
+	if state.Created.ValueBool() && !state.RetainOnDelete.ValueBool() {
+		object := state.secretObject()
+		helper, err := newObjectHelper(ctx, r.provider, &state.APIOptionsModel, object)
+		if err != nil {
+			resp.Diagnostics.AddError("Failed to create resource helper", err.Error())
+			return
+		}
+		err = helper.Delete(ctx, object)
+		if err != nil {
+			resp.Diagnostics.AddError("Delete failed", err.Error())
+			return
+		}
+	}
+	resp.State.RemoveResource(ctx)
+}
@@ -0,0 +1,317 @@
+package tfprovider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/davidjspooner/terraform-provider-kubernetes/internal/generic/kube"
+	"github.com/davidjspooner/terraform-provider-kubernetes/internal/terraform/tfparts"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ resource.Resource = &ResourceKubeTypedSecret{}
+var _ resource.ResourceWithModifyPlan = &ResourceKubeTypedSecret{}
+
+func init() {
+	// Register the resource with the provider.
+	RegisterResource(func() resource.Resource {
+		r := ResourceKubeTypedSecret{}
+		r.ResourceBase.tfTypeNameSuffix = "_typed_secret"
+		attr := map[string]schema.Attribute{
+			"name": schema.StringAttribute{
+				MarkdownDescription: "Name of the secret",
+				Required:            true,
+				PlanModifiers:       []planmodifier.String{stringplanmodifier.RequiresReplace()},
+			},
+			"namespace": schema.StringAttribute{
+				MarkdownDescription: "Namespace of the secret",
+				Optional:            true,
+				PlanModifiers:       []planmodifier.String{stringplanmodifier.RequiresReplace()},
+			},
+			"labels": schema.MapAttribute{
+				MarkdownDescription: "Labels of the secret",
+				ElementType:         types.StringType,
+				Optional:            true,
+			},
+			"annotations": schema.MapAttribute{
+				MarkdownDescription: "Annotations of the secret",
+				ElementType:         types.StringType,
+				Optional:            true,
+			},
+			"tls": schema.SingleNestedAttribute{
+				MarkdownDescription: "Build a kubernetes.io/tls secret from PEM files. The key must match the first certificate and each certificate must be signed by the next one",
+				Optional:            true,
+				Attributes: map[string]schema.Attribute{
+					"cert_file": schema.StringAttribute{
+						MarkdownDescription: "File containing the certificate followed by any intermediate certificates",
+						Required:            true,
+					},
+					"key_file": schema.StringAttribute{
+						MarkdownDescription: "File containing the private key",
+						Required:            true,
+					},
+					"ca_file": schema.StringAttribute{
+						MarkdownDescription: "File containing the CA certificate which is stored as ca.crt",
+						Optional:            true,
+					},
+				},
+			},
+			"docker_registry": schema.ListNestedAttribute{
+				MarkdownDescription: "Build a kubernetes.io/dockerconfigjson secret from registry credentials",
+				Optional:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"server": schema.StringAttribute{
+							MarkdownDescription: "Registry server ( eg ghcr.io )",
+							Required:            true,
+						},
+						"username": schema.StringAttribute{
+							MarkdownDescription: "Registry username",
+							Required:            true,
+						},
+						"password": schema.StringAttribute{
+							MarkdownDescription: "Registry password or token",
+							Required:            true,
+							Sensitive:           true,
+						},
+						"email": schema.StringAttribute{
+							MarkdownDescription: "Registry email",
+							Optional:            true,
+						},
+					},
+				},
+			},
+			"basic_auth": schema.SingleNestedAttribute{
+				MarkdownDescription: "Build a kubernetes.io/basic-auth secret",
+				Optional:            true,
+				Attributes: map[string]schema.Attribute{
+					"username": schema.StringAttribute{
+						MarkdownDescription: "Username",
+						Optional:            true,
+					},
+					"password": schema.StringAttribute{
+						MarkdownDescription: "Password",
+						Optional:            true,
+						Sensitive:           true,
+					},
+				},
+			},
+			"ssh_auth": schema.SingleNestedAttribute{
+				MarkdownDescription: "Build a kubernetes.io/ssh-auth secret",
+				Optional:            true,
+				Attributes: map[string]schema.Attribute{
+					"private_key_file": schema.StringAttribute{
+						MarkdownDescription: "File containing the private key",
+						Required:            true,
+					},
+				},
+			},
+			"hash": schema.StringAttribute{
+				MarkdownDescription: "Hash of the secret type and data, which changes when the files change or the secret is changed outside of terraform",
+				Computed:            true,
+			},
+		}
+
+		r.schema = schema.Schema{
+			// This description is used by the documentation generator and the language server.
+			MarkdownDescription: "Builds a correctly typed Secret from local inputs. Exactly one of tls, docker_registry, basic_auth or ssh_auth must be set",
+
+			Attributes: MergeResourceAttributes(
+				attr,
+				tfparts.FetchRequestAttributes(),
+				tfparts.ApiOptionsResourceAttributes(),
+			),
+		}
+
+		return &r
+	})
+}
+
+// ResourceKubeTypedSecret defines the resource implementation.
+type ResourceKubeTypedSecret struct {
+	ResourceBase[*TypedSecretModel]
+}
+
+type TLSSecretModel struct {
+	CertFile types.String `tfsdk:"cert_file"`
+	KeyFile  types.String `tfsdk:"key_file"`
+	CAFile   types.String `tfsdk:"ca_file"`
+}
+
+type DockerRegistryModel struct {
+	Server   types.String `tfsdk:"server"`
+	Username types.String `tfsdk:"username"`
+	Password types.String `tfsdk:"password"`
+	Email    types.String `tfsdk:"email"`
+}
+
+type BasicAuthModel struct {
+	Username types.String `tfsdk:"username"`
+	Password types.String `tfsdk:"password"`
+}
+
+type SSHAuthModel struct {
+	PrivateKeyFile types.String `tfsdk:"private_key_file"`
+}
+
+// TypedSecretModel describes the resource data model.
+type TypedSecretModel struct {
+	Name           types.String          `tfsdk:"name"`
+	Namespace      types.String          `tfsdk:"namespace"`
+	Labels         types.Map             `tfsdk:"labels"`
+	Annotations    types.Map             `tfsdk:"annotations"`
+	TLS            *TLSSecretModel       `tfsdk:"tls"`
+	DockerRegistry []DockerRegistryModel `tfsdk:"docker_registry"`
+	BasicAuth      *BasicAuthModel       `tfsdk:"basic_auth"`
+	SSHAuth        *SSHAuthModel         `tfsdk:"ssh_auth"`
+	Hash           types.String          `tfsdk:"hash"`
+
+	tfparts.APIOptionsModel
+	tfparts.FetchMap
+}
+
+func (model *TypedSecretModel) typedSecret() (*kube.TypedSecret, error) {
+	ctx := context.Background()
+	s := &kube.TypedSecret{
+		Name:      model.Name.ValueString(),
+		Namespace: model.Namespace.ValueString(),
+	}
+	diags := model.Labels.ElementsAs(ctx, &s.Labels, false)
+	diags.Append(model.Annotations.ElementsAs(ctx, &s.Annotations, false)...)
+	if diags.HasError() {
+		return nil, tfparts.DiagsToGoError(diags)
+	}
+	if model.TLS != nil {
+		s.TLS = &kube.TLSSecretOptions{
+			CertFile: model.TLS.CertFile.ValueString(),
+			KeyFile:  model.TLS.KeyFile.ValueString(),
+			CAFile:   model.TLS.CAFile.ValueString(),
+		}
+	}
+	for _, registry := range model.DockerRegistry {
+		s.DockerRegistry = append(s.DockerRegistry, kube.DockerRegistryCredential{
+			Server:   registry.Server.ValueString(),
+			Username: registry.Username.ValueString(),
+			Password: registry.Password.ValueString(),
+			Email:    registry.Email.ValueString(),
+		})
+	}
+	if model.BasicAuth != nil {
+		s.BasicAuth = &kube.BasicAuthOptions{
+			Username: model.BasicAuth.Username.ValueString(),
+			Password: model.BasicAuth.Password.ValueString(),
+		}
+	}
+	if model.SSHAuth != nil {
+		s.SSHAuth = &kube.SSHAuthOptions{
+			PrivateKeyFile: model.SSHAuth.PrivateKeyFile.ValueString(),
+		}
+	}
+	return s, nil
+}
+
+// BuildManifest also records the hash of the data so it is known after apply.
+func (model *TypedSecretModel) BuildManifest(manifest *unstructured.Unstructured) error {
+	s, err := model.typedSecret()
+	if err != nil {
+		return err
+	}
+	*manifest, err = s.Build()
+	if err != nil {
+		return err
+	}
+	hash, err := kube.ContentHash(*manifest)
+	if err != nil {
+		return err
+	}
+	model.Hash = types.StringValue(hash)
+	return nil
+}
+
+// UpdateFrom records the hash of the live type and data, so a Secret which was changed outside of
+// terraform plans an update back to the files.
+func (model *TypedSecretModel) UpdateFrom(manifest unstructured.Unstructured) error {
+	if manifest.Object == nil {
+		return nil
+	}
+	hash, err := kube.ContentHash(manifest)
+	if err != nil {
+		return err
+	}
+	model.Hash = types.StringValue(hash)
+	return nil
+}
+
+func (model *TypedSecretModel) GetResouceKey() (kube.ResourceKey, error) {
+	name := model.Name.ValueString()
+	if name == "" {
+		return kube.ResourceKey{}, fmt.Errorf("name is empty")
+	}
+	k := kube.ResourceKey{
+		ApiVersion: "v1",
+		Kind:       "Secret",
+	}
+	k.Metadata.Name = name
+	namespace := model.Namespace.ValueString()
+	if namespace != "" {
+		k.Metadata.Namespace = &namespace
+	}
+	return k, nil
+}
+
+func (r *ResourceKubeTypedSecret) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	r.ResourceBase.Metadata(ctx, req, resp)
+}
+
+func (r *ResourceKubeTypedSecret) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = r.schema
+}
+
+func (r *ResourceKubeTypedSecret) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	r.ResourceBase.Configure(ctx, req, resp)
+}
+
+// ModifyPlan computes the hash during plan so changed files cause an update.
+func (r *ResourceKubeTypedSecret) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if req.Plan.Raw.IsNull() || !req.Config.Raw.IsFullyKnown() {
+		return
+	}
+	plan := &TypedSecretModel{}
+	resp.Diagnostics.Append(req.Plan.Get(ctx, plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	var manifest unstructured.Unstructured
+	err := plan.BuildManifest(&manifest)
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to build secret", err.Error())
+		return
+	}
+	resp.Diagnostics.Append(resp.Plan.Set(ctx, plan)...)
+}
+
+func (r *ResourceKubeTypedSecret) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	plan := &TypedSecretModel{}
+	r.ResourceBase.Create(ctx, plan, req, resp)
+}
+
+func (r *ResourceKubeTypedSecret) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	state := &TypedSecretModel{}
+	r.ResourceBase.Read(ctx, state, req, resp)
+}
+
+func (r *ResourceKubeTypedSecret) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	plan := &TypedSecretModel{}
+	r.ResourceBase.Update(ctx, plan, req, resp)
+}
+
+func (r *ResourceKubeTypedSecret) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	state := &TypedSecretModel{}
+	r.ResourceBase.Delete(ctx, state, req, resp)
+}
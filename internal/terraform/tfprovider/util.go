@@ -1,6 +1,7 @@
 package tfprovider
 
 import (
+	"github.com/davidjspooner/terraform-provider-kubernetes/internal/generic/kube"
 	dschema "github.com/hashicorp/terraform-plugin-framework/datasource/schema"
 	rschema "github.com/hashicorp/terraform-plugin-framework/resource/schema"
 )
@@ -24,3 +25,15 @@ func MergeDataAttributes(attrs ...map[string]dschema.Attribute) map[string]dsche
 	}
 	return merged
 }
+
+// enableDiscovery lets document transforms ask the cluster whether a kind is namespaced.
+func enableDiscovery(fsds kube.FileSetDefs, provider *KubeProvider) {
+	if provider == nil {
+		return
+	}
+	for _, fsd := range fsds {
+		if fsd.Transform != nil {
+			fsd.Transform.IsNamespaced = provider.Shared.IsNamespaced
+		}
+	}
+}